@@ -0,0 +1,707 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/entry"
+	"github.com/kube-sailmaker/template-gen/functions"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/schema"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/task"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `usage: template-gen <command> [flags]
+
+commands:
+  generate    render the manifests of a release into the output dir
+  validate    resolve every app of a release without writing anything, or check manifest
+              files against the json schemas, eg: validate [flags] apps/busybox.yaml
+  list-apps   list the app manifests found in the app dir
+  explain     print the resolved values of one app, eg: explain [flags] busybox
+  templates   print the app templates of one app merged with the templates they extend,
+              for every environment or --env only, aliases of the environments are resolved
+              with --resource-dir, eg: templates [flags] busybox
+  schema      print the json schema of a manifest kind, eg: schema Application,
+              or write the schema of every kind into --output-dir
+
+flags:
+  --spec          release spec file (yaml or json), - reads stdin
+  --release       selects one release of a multi release spec
+  --app-dir       directory or zip/tar archive of the app manifests
+  --resource-dir  directory or zip/tar archive of the provider manifests
+  --output-dir    directory the manifests are written to (generate)
+  --env           overrides the environment of the release spec
+  --namespace     overrides the namespace of the release spec
+  --format        raw manifests per app, a helm chart or a kustomize base with
+                  overlays per release, or stream to stdout (generate)
+  --source-comments  prefix each streamed object with its app and file
+  --strict        report warnings as errors (validate)
+  --lenient       ignore unknown manifest fields, for manifests predating strict decoding
+  --kind          manifest kind of the files to validate, guessed from their path by default
+  --output        table or json
+`
+
+const (
+	outputTable = "table"
+	outputJson  = "json"
+
+	formatRaw       = "raw"
+	formatHelm      = "helm"
+	formatKustomize = "kustomize"
+	formatStream    = "stream"
+)
+
+type options struct {
+	spec        string
+	appDir      string
+	resourceDir string
+	outputDir   string
+	env         string
+	namespace   string
+	output      string
+	format      string
+	comments    bool
+	strict      bool
+	lenient     bool
+	kind        string
+	release     string
+	args        []string
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "generate":
+		return generate(args[1:], stdin, stdout)
+	case "validate":
+		return validate(args[1:], stdin, stdout)
+	case "list-apps":
+		return listApps(args[1:], stdout)
+	case "explain":
+		return explain(args[1:], stdin, stdout)
+	case "schema":
+		return printSchema(args[1:], stdout)
+	case "templates":
+		return printTemplates(args[1:], stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	return fmt.Errorf("unknown command %s\n%s", args[0], usage)
+}
+
+func parseOptions(command string, args []string) (*options, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.StringVar(&opts.spec, "spec", "", "release spec file (yaml or json), - reads stdin")
+	flags.StringVar(&opts.appDir, "app-dir", "", "directory of the app manifests")
+	flags.StringVar(&opts.resourceDir, "resource-dir", "", "directory of the provider manifests")
+	flags.StringVar(&opts.outputDir, "output-dir", "", "directory the manifests are written to")
+	flags.StringVar(&opts.env, "env", "", "overrides the environment of the release spec")
+	flags.StringVar(&opts.namespace, "namespace", "", "overrides the namespace of the release spec")
+	flags.StringVar(&opts.release, "release", "", "selects one release of a multi release spec")
+	flags.StringVar(&opts.output, "output", outputTable, "table or json")
+	flags.StringVar(&opts.format, "format", formatRaw, "raw, helm, kustomize or stream")
+	flags.BoolVar(&opts.comments, "source-comments", false, "prefix each streamed object with its source")
+	flags.BoolVar(&opts.strict, "strict", false, "report warnings as errors")
+	flags.BoolVar(&opts.lenient, "lenient", false, "ignore unknown manifest fields")
+	flags.StringVar(&opts.kind, "kind", "", "manifest kind of the files to validate")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if opts.output != outputTable && opts.output != outputJson {
+		return nil, fmt.Errorf("unknown output %s, eg: table, json", opts.output)
+	}
+	switch opts.format {
+	case formatRaw, formatHelm, formatKustomize, formatStream:
+	default:
+		return nil, fmt.Errorf("unknown format %s, eg: raw, helm, kustomize, stream", opts.format)
+	}
+	opts.args = flags.Args()
+	return opts, nil
+}
+
+func (o *options) require(names ...string) error {
+	values := map[string]string{
+		"spec":         o.spec,
+		"app-dir":      o.appDir,
+		"resource-dir": o.resourceDir,
+		"output-dir":   o.outputDir,
+	}
+	for _, name := range names {
+		if values[name] == "" {
+			return fmt.Errorf("--%s is required", name)
+		}
+	}
+	return nil
+}
+
+//loadSpec reads the releases of the spec and applies the env and namespace overrides,
+//--release narrows a multi release spec down to one release
+func loadSpec(opts *options, stdin io.Reader) ([]model.AppSpec, error) {
+	var specs []model.AppSpec
+	overrides := entry.Overrides{Namespace: opts.namespace, Environment: opts.env}
+	if opts.spec == "-" {
+		content, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		specs, err = entry.ParseAppSpecWith(content, overrides)
+		if err != nil {
+			return nil, fmt.Errorf("[file]: stdin, [error]: %v", err)
+		}
+	} else {
+		var err error
+		specs, err = entry.LoadAppSpecWith(opts.spec, overrides)
+		if err != nil {
+			return nil, err
+		}
+	}
+	selected := make([]model.AppSpec, 0)
+	for _, spec := range specs {
+		spec.Normalise()
+		if opts.release != "" && spec.ReleaseName != opts.release {
+			continue
+		}
+		selected = append(selected, spec)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("release %s not found in %s", opts.release, opts.spec)
+	}
+	return selected, nil
+}
+
+func generate(args []string, stdin io.Reader, stdout io.Writer) error {
+	opts, err := parseOptions("generate", args)
+	if err != nil {
+		return err
+	}
+	if opts.format == formatStream {
+		return stream(opts, stdin, stdout)
+	}
+	err = opts.require("spec", "app-dir", "resource-dir", "output-dir")
+	if err != nil {
+		return err
+	}
+	specs, err := loadSpec(opts, stdin)
+	if err != nil {
+		return err
+	}
+	apps, provider, err := openSources(opts)
+	if err != nil {
+		return err
+	}
+	summaries := make([]*model.DeploymentItemSummary, 0)
+	for i := range specs {
+		summary, err := render(opts.format, &specs[i], apps, provider, opts.outputDir)
+		if err != nil {
+			return err
+		}
+		summaries = append(summaries, summary)
+	}
+	if opts.output == outputJson {
+		return printJson(stdout, summaries)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tKIND\tPATH")
+	for _, summary := range summaries {
+		for _, item := range summary.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", summary.Namespace, item.Name, item.Kind, item.Path)
+		}
+	}
+	return w.Flush()
+}
+
+//stream writes the objects of every release to stdout instead of the output dir
+func stream(opts *options, stdin io.Reader, stdout io.Writer) error {
+	err := opts.require("spec", "app-dir", "resource-dir")
+	if err != nil {
+		return err
+	}
+	specs, err := loadSpec(opts, stdin)
+	if err != nil {
+		return err
+	}
+	apps, provider, err := openSources(opts)
+	if err != nil {
+		return err
+	}
+	for i := range specs {
+		release, err := entry.ProcessReleaseFrom(&specs[i], apps, provider)
+		if err != nil {
+			return err
+		}
+		err = templates.Write(release, stdout, opts.comments)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//render writes the release in the given format into outputDir
+func render(format string, appSpec *model.AppSpec, apps source.ManifestSource, provider source.ManifestSource, outputDir string) (*model.DeploymentItemSummary, error) {
+	if format == formatKustomize {
+		return entry.KustomizeRelease(appSpec, apps, provider, outputDir)
+	}
+	release, err := entry.ProcessReleaseFrom(appSpec, apps, provider)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatHelm {
+		return templates.RunChart(release, outputDir)
+	}
+	return templates.Run(release, outputDir)
+}
+
+//openSources opens the app and provider manifests of the options, decoded leniently with --lenient
+func openSources(opts *options) (source.ManifestSource, source.ManifestSource, error) {
+	apps, provider, err := entry.OpenSources(opts.appDir, opts.resourceDir)
+	if err != nil || !opts.lenient {
+		return apps, provider, err
+	}
+	return source.Lenient(apps), source.Lenient(provider), nil
+}
+
+//openApps opens the app manifests of the options, decoded leniently with --lenient
+func openApps(opts *options) (source.ManifestSource, error) {
+	apps, err := source.Open(opts.appDir)
+	if err != nil || !opts.lenient {
+		return apps, err
+	}
+	return source.Lenient(apps), nil
+}
+
+func validate(args []string, stdin io.Reader, stdout io.Writer) error {
+	opts, err := parseOptions("validate", args)
+	if err != nil {
+		return err
+	}
+	if opts.spec == "" && len(opts.args) > 0 {
+		return validateManifests(opts, stdout)
+	}
+	err = opts.require("spec", "app-dir", "resource-dir")
+	if err != nil {
+		return err
+	}
+	specs, err := loadSpec(opts, stdin)
+	if err != nil {
+		return err
+	}
+	apps, provider, err := openSources(opts)
+	if err != nil {
+		return err
+	}
+	results := make([]validation, 0)
+	errorCount := 0
+	for i := range specs {
+		diagnostics := entry.ValidateRelease(&specs[i], apps, provider, opts.strict)
+		errorCount += len(diagnostics.Errors())
+		results = append(results, validation{
+			Release:     specs[i].ReleaseName,
+			Namespace:   specs[i].Namespace,
+			Apps:        len(specs[i].Apps),
+			Diagnostics: diagnostics,
+		})
+	}
+	if opts.output == outputJson {
+		err = printJson(stdout, results)
+	} else {
+		err = printValidation(stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("validation failed with %d error(s)", errorCount)
+	}
+	return nil
+}
+
+type validation struct {
+	Release     string            `json:"release"`
+	Namespace   string            `json:"namespace"`
+	Apps        int               `json:"apps"`
+	Diagnostics model.Diagnostics `json:"diagnostics"`
+}
+
+func printValidation(stdout io.Writer, results []validation) error {
+	for _, r := range results {
+		if len(r.Diagnostics) == 0 {
+			fmt.Fprintf(stdout, "release %s is valid, %d app(s) in namespace %s\n", r.Release, r.Apps, r.Namespace)
+			continue
+		}
+		fmt.Fprintf(stdout, "release %s has %d error(s), %d warning(s)\n", r.Release, len(r.Diagnostics.Errors()), len(r.Diagnostics.Warnings()))
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tAPP\tFILE\tMESSAGE")
+		for _, d := range r.Diagnostics {
+			file := d.File
+			if file != "" && d.Line > 0 {
+				file = fmt.Sprintf("%s:%d", file, d.Line)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Severity, d.App, file, d.Message)
+		}
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type manifestValidation struct {
+	File        string            `json:"file"`
+	Kind        string            `json:"kind"`
+	Diagnostics model.Diagnostics `json:"diagnostics"`
+}
+
+//validateManifests checks manifest files against the json schema of their kind
+func validateManifests(opts *options, stdout io.Writer) error {
+	results := make([]manifestValidation, 0)
+	errorCount := 0
+	for _, file := range opts.args {
+		kind := opts.kind
+		if kind == "" {
+			kind = schema.KindOf(file)
+		}
+		content, err := functions.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		diagnostics, err := schema.Validate(kind, file, *content)
+		if err != nil {
+			return err
+		}
+		errorCount += len(diagnostics.Errors())
+		results = append(results, manifestValidation{File: file, Kind: kind, Diagnostics: diagnostics})
+	}
+	if opts.output == outputJson {
+		err := printJson(stdout, results)
+		if err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "FILE\tKIND\tMESSAGE")
+		for _, r := range results {
+			if len(r.Diagnostics) == 0 {
+				fmt.Fprintf(w, "%s\t%s\tvalid\n", r.File, r.Kind)
+			}
+			for _, d := range r.Diagnostics {
+				fmt.Fprintf(w, "%s:%d\t%s\t%s\n", d.File, d.Line, r.Kind, d.Message)
+			}
+		}
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("validation failed with %d error(s)", errorCount)
+	}
+	return nil
+}
+
+//printSchema prints the json schema of one kind, or writes the schemas of every kind into --output-dir
+func printSchema(args []string, stdout io.Writer) error {
+	opts, err := parseOptions("schema", args)
+	if err != nil {
+		return err
+	}
+	if opts.outputDir != "" {
+		err := os.MkdirAll(opts.outputDir, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		for _, kind := range schema.Kinds() {
+			file := filepath.Join(opts.outputDir, schema.FileName(kind))
+			err := writeSchema(kind, file)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, file)
+		}
+		return nil
+	}
+	if len(opts.args) != 1 {
+		return fmt.Errorf("schema requires one kind, eg: %s", strings.Join(schema.Kinds(), ", "))
+	}
+	s, err := schema.For(opts.args[0])
+	if err != nil {
+		return err
+	}
+	content, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(content)
+	return err
+}
+
+func writeSchema(kind string, file string) error {
+	s, err := schema.For(kind)
+	if err != nil {
+		return err
+	}
+	content, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+type appManifest struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	File string `json:"file"`
+}
+
+func listApps(args []string, stdout io.Writer) error {
+	opts, err := parseOptions("list-apps", args)
+	if err != nil {
+		return err
+	}
+	err = opts.require("app-dir")
+	if err != nil {
+		return err
+	}
+	src, err := openApps(opts)
+	if err != nil {
+		return err
+	}
+	files, err := src.List(".")
+	if err != nil {
+		return err
+	}
+	apps := make([]appManifest, 0)
+	for _, file := range files {
+		if filepath.Ext(file) != ".yaml" {
+			continue
+		}
+		application := &model.Application{}
+		_, err := functions.UnmarshalManifest(src, model.KindApplication, file, application)
+		if err != nil {
+			return err
+		}
+		kind := application.Kind
+		if kind == "" {
+			kind = "Deployment"
+		}
+		apps = append(apps, appManifest{
+			Name: strings.TrimSuffix(filepath.Base(file), ".yaml"),
+			Kind: kind,
+			File: file,
+		})
+	}
+	if opts.output == outputJson {
+		return printJson(stdout, apps)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tFILE")
+	for _, app := range apps {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", app.Name, app.Kind, app.File)
+	}
+	return w.Flush()
+}
+
+func explain(args []string, stdin io.Reader, stdout io.Writer) error {
+	opts, err := parseOptions("explain", args)
+	if err != nil {
+		return err
+	}
+	err = opts.require("spec", "app-dir", "resource-dir")
+	if err != nil {
+		return err
+	}
+	if len(opts.args) != 1 {
+		return errors.New("explain requires one app name, eg: explain [flags] busybox")
+	}
+	specs, err := loadSpec(opts, stdin)
+	if err != nil {
+		return err
+	}
+	var appSpec *model.AppSpec
+	for i := 0; i < len(specs) && appSpec == nil; i++ {
+		for _, app := range specs[i].Apps {
+			if app.Name == opts.args[0] {
+				appSpec = &specs[i]
+				appSpec.Apps = []model.App{app}
+				break
+			}
+		}
+	}
+	if appSpec == nil {
+		return fmt.Errorf("app %s is not part of any release of %s", opts.args[0], opts.spec)
+	}
+	apps, provider, err := openSources(opts)
+	if err != nil {
+		return err
+	}
+	release, err := entry.ProcessReleaseFrom(appSpec, apps, provider)
+	if err != nil {
+		return err
+	}
+	application := release.Application[0]
+	if opts.output == outputJson {
+		return printJson(stdout, application)
+	}
+	return printApplication(stdout, &application)
+}
+
+func printTemplates(args []string, stdout io.Writer) error {
+	opts, err := parseOptions("templates", args)
+	if err != nil {
+		return err
+	}
+	err = opts.require("app-dir")
+	if err != nil {
+		return err
+	}
+	if len(opts.args) != 1 {
+		return errors.New("templates requires one app name, eg: templates [flags] busybox")
+	}
+	apps, err := openApps(opts)
+	if err != nil {
+		return err
+	}
+	application, err := task.LoadApplicationFrom(opts.args[0], apps)
+	if err != nil {
+		return err
+	}
+	env := opts.env
+	if opts.resourceDir != "" {
+		//resolve the aliases of the environment registry of the provider
+		_, provider, err := openSources(opts)
+		if err != nil {
+			return err
+		}
+		environments, err := task.LoadEnvironments(provider)
+		if err != nil {
+			return err
+		}
+		if environments != nil {
+			if env != "" {
+				environment, err := environments.Resolve(env)
+				if err != nil {
+					return err
+				}
+				env = environment.Name
+			}
+			application, err = environments.Normalize(application)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var resolved []task.ResolvedTemplate
+	if env != "" {
+		r, rerr := task.ResolveTemplate(application, env)
+		if rerr == nil {
+			resolved = []task.ResolvedTemplate{*r}
+		}
+		err = rerr
+	} else {
+		resolved, err = task.ResolveTemplates(application)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.output == outputJson {
+		return printJson(stdout, resolved)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENV\tKEY\tVALUE\tFROM")
+	for _, r := range resolved {
+		for _, k := range sortedKeys(r.Config) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, k, r.Config[k], r.Origin[k])
+		}
+	}
+	return w.Flush()
+}
+
+func printApplication(stdout io.Writer, application *templates.Application) error {
+	_, kind := templates.GetRequiredTemplates(application)
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", application.Name)
+	fmt.Fprintf(w, "Kind:\t%s\n", kind)
+	if application.Schedule != "" {
+		fmt.Fprintf(w, "Schedule:\t%s\n", strings.TrimSpace(application.Schedule+" "+application.TimeZone))
+	}
+	fmt.Fprintf(w, "Image:\t%s\n", application.ImageRef())
+	fmt.Fprintf(w, "Replicas:\t%s\n", application.Replicas)
+	fmt.Fprintf(w, "Limits:\t%s\n", formatMap(application.Limits))
+	fmt.Fprintf(w, "Requests:\t%s\n", formatMap(application.Requests))
+	ports := make([]string, 0, len(application.Ports))
+	for _, p := range application.Ports {
+		ports = append(ports, fmt.Sprintf("%s=%d->%d/%s", p.Name, p.Port, p.ContainerPort, p.Protocol))
+	}
+	fmt.Fprintf(w, "Ports:\t%s\n", strings.Join(ports, ", "))
+	if len(application.VolumeClaims) > 0 {
+		claims := make([]string, 0, len(application.VolumeClaims))
+		for _, c := range application.VolumeClaims {
+			claims = append(claims, fmt.Sprintf("%s=%s@%s", c.Name, c.Size, c.MountPath))
+		}
+		fmt.Fprintf(w, "Volumes:\t%s\n", strings.Join(claims, ", "))
+	}
+	fmt.Fprintf(w, "Env:\t%s\n", formatMap(application.EnvVars))
+	if len(application.ConfigEnvVars) > 0 {
+		fmt.Fprintf(w, "Config:\t%s\n", formatMap(application.ConfigEnvVars))
+	}
+	fmt.Fprintf(w, "Secrets:\t%s\n", strings.Join(sortedKeys(application.SecretEnvVars), ", "))
+	fmt.Fprintf(w, "Command:\t%s\n", strings.Join(application.Command, " "))
+	fmt.Fprintf(w, "Entrypoint:\t%s\n", strings.Join(application.Entrypoint, " "))
+	if len(application.Sources) > 0 {
+		fmt.Fprintln(w, "\nFIELD\tVALUE\tSOURCE")
+		for _, s := range application.Sources {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Field, s.Value, s.Source)
+		}
+	}
+	return w.Flush()
+}
+
+func formatMap(items map[string]string) string {
+	keys := sortedKeys(items)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, items[k]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+//sortedKeys lists the keys of items only, eg: to print secrets without their values
+func sortedKeys(items map[string]string) []string {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printJson(stdout io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+const (
+	appDir      = "../../sample-manifest/user/apps"
+	resourceDir = "../../sample-manifest/provider"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	err := run([]string{"deploy"}, nil, &bytes.Buffer{})
+	test.NotNull(t, err)
+	test.EqualTo(t, true, strings.HasPrefix(fmt.Sprintf("%v", err), "unknown command deploy"))
+}
+
+func TestListApps(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := run([]string{"list-apps", "--app-dir", appDir}, nil, out)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "eod-job"))
+}
+
+func TestExplainReadsSpecFromStdin(t *testing.T) {
+	spec := `{"namespace": "apps", "environment": "prod", "apps": [{"name": "busybox", "version": "1.0"}]}`
+	out := &bytes.Buffer{}
+	args := []string{"explain", "--spec", "-", "--app-dir", appDir, "--resource-dir", resourceDir, "--env", "test", "busybox"}
+	err := run(args, strings.NewReader(spec), out)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "template/test"))
+}
+
+func TestExplainPrintsConfigMapEnvVars(t *testing.T) {
+	spec := `{"namespace": "apps", "apps": [{"name": "nightly-report", "version": "1.0"}]}`
+	out := &bytes.Buffer{}
+	args := []string{"explain", "--spec", "-", "--app-dir", appDir, "--resource-dir", resourceDir, "--env", "test", "nightly-report"}
+	err := run(args, strings.NewReader(spec), out)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "Config:      POSTGRES_CONTACT_POINTS="))
+}
+
+func TestEnvOverrideAppliesBeforeValidation(t *testing.T) {
+	spec := "namespace: apps\napps:\n  - name: busybox\n    version: \"1.0\"\n"
+	out := &bytes.Buffer{}
+	args := []string{"explain", "--spec", "-", "--app-dir", appDir, "--resource-dir", resourceDir, "--env", "test", "busybox"}
+	err := run(args, strings.NewReader(spec), out)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "template/test"))
+}
+
+func TestValidateRequiresSpec(t *testing.T) {
+	err := run([]string{"validate", "--app-dir", appDir, "--resource-dir", resourceDir}, nil, &bytes.Buffer{})
+	test.NotNull(t, err)
+	test.EqualTo(t, "--spec is required", fmt.Sprintf("%v", err))
+}
+
+func TestTemplatesResolvesInheritance(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := run([]string{"templates", "--app-dir", appDir, "--env", "sit", "busybox"}, nil, out)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "sit  logging_level  DEBUG  test"))
+}
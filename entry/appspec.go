@@ -0,0 +1,128 @@
+package entry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/functions"
+	"github.com/kube-sailmaker/template-gen/model"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+//Overrides replace the namespace and environment of every release of a spec, eg: the --namespace and --env flags
+type Overrides struct {
+	Namespace   string
+	Environment string
+}
+
+//LoadAppSpec reads the release specs of a yaml or json file, a multi document file holds one release per document
+func LoadAppSpec(path string) ([]model.AppSpec, error) {
+	return LoadAppSpecWith(path, Overrides{})
+}
+
+//LoadAppSpecWith reads the release specs of a file like LoadAppSpec, the overrides are set before the releases are validated
+func LoadAppSpecWith(path string, overrides Overrides) ([]model.AppSpec, error) {
+	content, err := functions.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := ParseAppSpecWith(*content, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("[file]: %s, [error]: %v", path, err)
+	}
+	return specs, nil
+}
+
+//ParseAppSpec expands ${VAR} references of the values from the environment, decodes every document strictly
+//so unknown or mistyped fields are reported with their line and the closest known field, then validates each release
+func ParseAppSpec(content []byte) ([]model.AppSpec, error) {
+	return ParseAppSpecWith(content, Overrides{})
+}
+
+//ParseAppSpecWith parses the release specs like ParseAppSpec, the overrides are set before the releases are validated,
+//eg: a spec without environment rendered with --env test
+func ParseAppSpecWith(content []byte, overrides Overrides) ([]model.AppSpec, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	specs := make([]model.AppSpec, 0)
+	for document := 1; ; document++ {
+		spec := model.AppSpec{}
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = expandEnv(&node)
+			if err != nil {
+				return nil, err
+			}
+			if fields := functions.UnknownFields(&node, &spec); len(fields) > 0 {
+				return nil, fmt.Errorf("release %d: %v", document, (&model.ManifestParseError{Fields: fields}).Message())
+			}
+			err = node.Decode(&spec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("release %d: %v", document, strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		if reflect.DeepEqual(spec, model.AppSpec{}) {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("no release found")
+	}
+	for i := range specs {
+		if overrides.Namespace != "" {
+			//the release keeps the name of the namespace of the spec
+			specs[i].Normalise()
+			specs[i].Namespace = overrides.Namespace
+		}
+		if overrides.Environment != "" {
+			specs[i].Environment = overrides.Environment
+		}
+		err := specs[i].Validate()
+		if err != nil {
+			return nil, fmt.Errorf("release %d: %v", i+1, err)
+		}
+	}
+	return specs, nil
+}
+
+//expandEnv replaces the ${VAR} references of the scalar values of node, keys and comments are left as written
+func expandEnv(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var missing error
+		expanded := envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := envReference.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok && missing == nil {
+				missing = fmt.Errorf("line %d: environment variable %s is not set", node.Line, name)
+			}
+			return value
+		})
+		if expanded != node.Value && node.Style == 0 {
+			//a plain scalar is resolved again, eg: replicas: ${REPLICAS}
+			node.Tag = ""
+		}
+		node.Value = expanded
+		return missing
+	}
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		err := expandEnv(child)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
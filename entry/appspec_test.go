@@ -0,0 +1,66 @@
+package entry
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/test"
+	"os"
+	"testing"
+)
+
+func TestLoadAppSpec(t *testing.T) {
+	specs, err := LoadAppSpec("../sample-manifest/user/release.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(specs))
+	test.EqualTo(t, "Release-2", specs[0].ReleaseName)
+	test.EqualTo(t, "nginx", specs[1].Apps[0].Name)
+}
+
+func TestParseAppSpecExpandsEnvironment(t *testing.T) {
+	os.Setenv("TEMPLATE_GEN_VERSION", "1.2.0")
+	defer os.Unsetenv("TEMPLATE_GEN_VERSION")
+	content := `{"namespace": "apps", "environment": "test", "apps": [{"name": "busybox", "version": "${TEMPLATE_GEN_VERSION}"}]}`
+	specs, err := ParseAppSpec([]byte(content))
+	test.Null(t, err)
+	test.EqualTo(t, "1.2.0", specs[0].Apps[0].Version)
+}
+
+func TestParseAppSpecReportsMissingEnvironment(t *testing.T) {
+	content := "namespace: apps\nenvironment: ${TEMPLATE_GEN_UNDEFINED}\n"
+	_, err := ParseAppSpec([]byte(content))
+	test.NotNull(t, err)
+	test.EqualTo(t, "line 2: environment variable TEMPLATE_GEN_UNDEFINED is not set", fmt.Sprintf("%v", err))
+}
+
+func TestParseAppSpecReportsSchemaErrorsWithLine(t *testing.T) {
+	content := "namespace: apps\nenvironment: test\napps:\n  - name: busybox\n---\nnamespace: apps\nenviroment: test\n"
+	_, err := ParseAppSpec([]byte(content))
+	test.NotNull(t, err)
+	test.EqualTo(t, "release 2: line 7: unknown field enviroment of model.AppSpec, did you mean environment?", fmt.Sprintf("%v", err))
+}
+
+func TestParseAppSpecValidatesRelease(t *testing.T) {
+	_, err := ParseAppSpec([]byte("namespace: apps\nenvironment: test\n"))
+	test.NotNull(t, err)
+	test.EqualTo(t, "release 1: app to deploy cannot be empty", fmt.Sprintf("%v", err))
+}
+
+func TestParseAppSpecExpandsValuesOnly(t *testing.T) {
+	os.Setenv("TEMPLATE_GEN_VERSION", "1.2.0 # \"not a comment\"")
+	defer os.Unsetenv("TEMPLATE_GEN_VERSION")
+	content := "#uses ${TEMPLATE_GEN_UNDEFINED}\nnamespace: apps\nenvironment: test\napps:\n  - name: busybox\n    version: ${TEMPLATE_GEN_VERSION}\n"
+	specs, err := ParseAppSpec([]byte(content))
+	test.Null(t, err)
+	test.EqualTo(t, "1.2.0 # \"not a comment\"", specs[0].Apps[0].Version)
+}
+
+func TestParseAppSpecAppliesOverridesBeforeValidating(t *testing.T) {
+	content := "namespace: apps\napps:\n  - name: busybox\n"
+	_, err := ParseAppSpec([]byte(content))
+	test.EqualTo(t, "release 1: environment is required", fmt.Sprintf("%v", err))
+
+	specs, err := ParseAppSpecWith([]byte(content), Overrides{Namespace: "batch", Environment: "test"})
+	test.Null(t, err)
+	test.EqualTo(t, "test", specs[0].Environment)
+	test.EqualTo(t, "batch", specs[0].Namespace)
+	test.EqualTo(t, "apps", specs[0].ReleaseName)
+}
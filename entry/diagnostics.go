@@ -0,0 +1,69 @@
+package entry
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/task"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"io/ioutil"
+	"log"
+)
+
+//ValidateRelease resolves and renders every app of the release without writing anything, and reports
+//every error and warning found across all apps instead of stopping at the first one.
+//Strict promotes the warnings, eg: a missing mixin or env type, to errors
+func ValidateRelease(appSpec *model.AppSpec, apps source.ManifestSource, provider source.ManifestSource, strict bool) model.Diagnostics {
+	validationErr := appSpec.Validate()
+	if validationErr != nil {
+		return model.Diagnostics{model.NewDiagnostic(model.SeverityError, validationErr)}
+	}
+	appSpec.Normalise()
+
+	releaseTemplate, diagnostics := processApps(appSpec, apps, provider)
+	if !diagnostics.HasErrors() {
+		err := templates.Write(releaseTemplate, ioutil.Discard, false)
+		if err != nil {
+			diagnostics = append(diagnostics, model.NewDiagnostic(model.SeverityError, err))
+		}
+	}
+	if strict {
+		return diagnostics.Strict()
+	}
+	return diagnostics
+}
+
+//processApps resolves every app of the release, the diagnostics hold the errors of each failing app
+//followed by the warnings of every app
+func processApps(appSpec *model.AppSpec, apps source.ManifestSource, provider source.ManifestSource) (*templates.ReleaseTemplate, model.Diagnostics) {
+	appTemplate := make([]templates.Application, 0)
+	diagnostics := make(model.Diagnostics, 0)
+	warnings := make(model.Diagnostics, 0)
+	for _, app := range appSpec.Apps {
+		application, err := task.ProcessApplicationFrom(&app, appSpec.ReleaseName, appSpec.Namespace, appSpec.Environment, apps, provider)
+		if report, ok := err.(model.Diagnostics); ok {
+			diagnostics = append(diagnostics, report.Errors()...)
+			warnings = append(warnings, report.Warnings()...)
+			continue
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, model.NewDiagnostic(model.SeverityError, err))
+			continue
+		}
+		warnings = append(warnings, application.Warnings...)
+		appTemplate = append(appTemplate, *application)
+	}
+
+	return &templates.ReleaseTemplate{
+		Namespace:    appSpec.Namespace,
+		ReleaseName:  appSpec.ReleaseName,
+		Environment:  appSpec.Environment,
+		ChartVersion: appSpec.ChartVersion,
+		Application:  appTemplate,
+	}, append(diagnostics, warnings...)
+}
+
+func logWarnings(diagnostics model.Diagnostics) {
+	for _, diagnostic := range diagnostics.Warnings() {
+		log.Print("[WARN] ", diagnostic)
+	}
+}
@@ -0,0 +1,103 @@
+package entry
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/fs"
+	"testing"
+)
+
+func diagnosticsSpec() *model.AppSpec {
+	return &model.AppSpec{
+		Namespace:   "apps",
+		ReleaseName: "release",
+		Environment: "test",
+		Apps: []model.App{
+			{Name: "api", Version: "1.0"},
+			{Name: "web", Version: "1.0"},
+			{Name: "worker", Version: "1.0"},
+		},
+	}
+}
+
+func diagnosticsSources() (source.ManifestSource, source.ManifestSource) {
+	apps := source.Map{
+		"api.yaml":    "name: api\nmixins:\n  - base/large\ntemplate:\n  - name: test\n",
+		"web.yaml":    "name: web\ntemplate:\n  - name: prod\n",
+		"worker.yaml": "name: worker\ntemplate:\n  - name: test\n    config:\n      cpu: c9\n",
+	}
+	provider := source.Map{
+		"mixins/base.yaml": "mixin:\n  - name: small\n    cpu: 250m\n",
+	}
+	return apps, provider
+}
+
+func TestValidateReleaseCollectsEveryProblem(t *testing.T) {
+	apps, provider := diagnosticsSources()
+	diagnostics := ValidateRelease(diagnosticsSpec(), apps, provider, false)
+	test.EqualTo(t, 3, len(diagnostics))
+	test.EqualTo(t, 2, len(diagnostics.Errors()))
+	test.EqualTo(t, "web", diagnostics[0].App)
+	test.EqualTo(t, "worker", diagnostics[1].App)
+	test.EqualTo(t, 5, diagnostics[1].Line)
+
+	warning := diagnostics.Warnings()[0]
+	test.EqualTo(t, "api", warning.App)
+	test.EqualTo(t, "mixins/base.yaml", warning.File)
+	test.EqualTo(t, "could not find matching mixin large", warning.Message)
+}
+
+func TestValidateReleaseStrict(t *testing.T) {
+	apps, provider := diagnosticsSources()
+	spec := diagnosticsSpec()
+	spec.Apps = spec.Apps[:1]
+	test.EqualTo(t, false, ValidateRelease(spec, apps, provider, false).HasErrors())
+	test.EqualTo(t, true, ValidateRelease(spec, apps, provider, true).HasErrors())
+}
+
+func TestProcessReleaseReturnsAllErrors(t *testing.T) {
+	apps, provider := diagnosticsSources()
+	_, err := ProcessReleaseFrom(diagnosticsSpec(), apps, provider)
+	var diagnostics model.Diagnostics
+	test.EqualTo(t, true, errors.As(err, &diagnostics))
+	test.EqualTo(t, 2, len(diagnostics))
+
+	var unknown *model.UnknownEnvironmentError
+	test.EqualTo(t, true, errors.As(err, &unknown))
+	test.EqualTo(t, "web", unknown.App)
+}
+
+func TestValidateReleaseCollectsEveryProblemOfAnApp(t *testing.T) {
+	apps := source.Map{
+		"batch.yaml": "name: batch\nkind: Jobs\ntemplate:\n  - name: test\n    config:\n      cpu: c9\n",
+	}
+	spec := &model.AppSpec{Namespace: "apps", Environment: "test", Apps: []model.App{{Name: "batch"}, {Name: "missing"}}}
+	diagnostics := ValidateRelease(spec, apps, source.Map{}, false)
+	test.EqualTo(t, 3, len(diagnostics.Errors()))
+	test.EqualTo(t, 2, diagnostics[0].Line)
+	test.EqualTo(t, 6, diagnostics[1].Line)
+	test.EqualTo(t, "missing", diagnostics[2].App)
+
+	_, err := ProcessReleaseFrom(spec, apps, source.Map{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "kind", invalid.Field)
+	test.EqualTo(t, true, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestValidateReleaseKeepsWarningsOfAFailingApp(t *testing.T) {
+	apps := source.Map{
+		"api.yaml": "name: api\nmixins:\n  - base/large\ntemplate:\n  - name: test\n    config:\n      cpu: c9\n",
+	}
+	provider := source.Map{
+		"mixins/base.yaml": "mixin:\n  - name: small\n    cpu: 250m\n",
+	}
+	spec := &model.AppSpec{Namespace: "apps", Environment: "test", Apps: []model.App{{Name: "api"}}}
+	diagnostics := ValidateRelease(spec, apps, provider, false)
+	test.EqualTo(t, 2, len(diagnostics))
+	test.EqualTo(t, model.SeverityError, diagnostics[0].Severity)
+	test.EqualTo(t, 7, diagnostics[0].Line)
+	test.EqualTo(t, "could not find matching mixin large", diagnostics.Warnings()[0].Message)
+}
@@ -2,30 +2,75 @@ package entry
 
 import (
 	"github.com/kube-sailmaker/template-gen/model"
-	"github.com/kube-sailmaker/template-gen/task"
+	"github.com/kube-sailmaker/template-gen/source"
 	templates "github.com/kube-sailmaker/template-gen/template"
+	"io"
 )
 
 func TemplateGenerator(appSpec *model.AppSpec, appDir string, resourceDir string, outputDir string) (*model.DeploymentItemSummary, error) {
-	appTemplate := make([]templates.Application, 0)
+	releaseTemplate, err := ProcessRelease(appSpec, appDir, resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	return templates.Run(releaseTemplate, outputDir)
+}
+
+//ChartGenerator writes the release as a helm chart into outputDir
+func ChartGenerator(appSpec *model.AppSpec, appDir string, resourceDir string, outputDir string) (*model.DeploymentItemSummary, error) {
+	releaseTemplate, err := ProcessRelease(appSpec, appDir, resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	return templates.RunChart(releaseTemplate, outputDir)
+}
 
+//StreamGenerator writes every object of the release to w as one multi document yaml stream,
+//eg: to pipe it into kubectl apply -f -
+func StreamGenerator(appSpec *model.AppSpec, appDir string, resourceDir string, w io.Writer, sourceComments bool) error {
+	releaseTemplate, err := ProcessRelease(appSpec, appDir, resourceDir)
+	if err != nil {
+		return err
+	}
+	return templates.Write(releaseTemplate, w, sourceComments)
+}
+
+//ProcessRelease processes the release reading the manifests from the directories or archives at appDir and resourceDir,
+//see ProcessReleaseFrom
+func ProcessRelease(appSpec *model.AppSpec, appDir string, resourceDir string) (*templates.ReleaseTemplate, error) {
+	apps, provider, err := OpenSources(appDir, resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	return ProcessReleaseFrom(appSpec, apps, provider)
+}
+
+//OpenSources opens the app and provider manifests of a directory or a zip/tar archive path
+func OpenSources(appDir string, resourceDir string) (source.ManifestSource, source.ManifestSource, error) {
+	apps, err := source.Open(appDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	provider, err := source.Open(resourceDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apps, provider, nil
+}
+
+//ProcessReleaseFrom validates the release and resolves the template values of every app without rendering them.
+//Combined with templates.Write it renders a release from any manifest source, eg: embedded or in-memory manifests.
+//Every app is resolved before failing, the error is then the model.Diagnostics of all the errors found
+func ProcessReleaseFrom(appSpec *model.AppSpec, apps source.ManifestSource, provider source.ManifestSource) (*templates.ReleaseTemplate, error) {
 	validationErr := appSpec.Validate()
 	if validationErr != nil {
 		return nil, validationErr
 	}
 	appSpec.Normalise()
 
-	for _, app := range appSpec.Apps {
-		application, err := task.ProcessApplication(&app, appSpec.ReleaseName, appSpec.Namespace, appSpec.Environment, appDir, resourceDir)
-		if err != nil {
-			return nil, err
-		}
-		appTemplate = append(appTemplate, *application)
-	}
-
-	releaseTemplate := templates.ReleaseTemplate{
-		Namespace:   appSpec.Namespace,
-		Application: appTemplate,
+	releaseTemplate, diagnostics := processApps(appSpec, apps, provider)
+	if diagnostics.HasErrors() {
+		return nil, diagnostics.Errors()
 	}
-	return templates.Run(&releaseTemplate, outputDir)
+	logWarnings(diagnostics)
+	return releaseTemplate, nil
 }
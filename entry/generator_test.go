@@ -3,6 +3,7 @@ package entry
 import (
 	"fmt"
 	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
 	"github.com/kube-sailmaker/template-gen/test"
 	"os"
 	"testing"
@@ -67,3 +68,16 @@ func GetAppSpec() *model.AppSpec {
 		Apps:        appList,
 	}
 }
+
+func TestProcessReleaseFromMemory(t *testing.T) {
+	apps := source.Map{
+		"busybox.yaml": "name: busybox\nmixins:\n  - base/small\ntemplate:\n  - name: test\n    config:\n      replicas: 3\n",
+	}
+	provider := source.Map{
+		"mixins/base.yaml": "mixin:\n  - name: small\n    cpu: 250m\n    memory: 128Mi\n",
+	}
+	release, err := ProcessReleaseFrom(GetAppSpec(), apps, provider)
+	test.Null(t, err)
+	test.EqualTo(t, "3", release.Application[0].Replicas)
+	test.EqualTo(t, "250m", release.Application[0].Limits["cpu"])
+}
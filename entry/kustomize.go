@@ -0,0 +1,85 @@
+package entry
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/task"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"sort"
+)
+
+//KustomizeGenerator writes the release as a kustomize base and an overlay for every
+//template name declared by the apps of the release, the default template of an app adds it to the
+//overlay of every environment of the provider registry
+func KustomizeGenerator(appSpec *model.AppSpec, appDir string, resourceDir string, outputDir string) (*model.DeploymentItemSummary, error) {
+	apps, provider, err := OpenSources(appDir, resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	return KustomizeRelease(appSpec, apps, provider, outputDir)
+}
+
+//KustomizeRelease writes the release read from the manifest sources as a kustomize base and overlays, see KustomizeGenerator
+func KustomizeRelease(appSpec *model.AppSpec, apps source.ManifestSource, provider source.ManifestSource, outputDir string) (*model.DeploymentItemSummary, error) {
+	validationErr := appSpec.Validate()
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	appSpec.Normalise()
+
+	registry, err := task.LoadEnvironments(provider)
+	if err != nil {
+		return nil, err
+	}
+	environments := make(map[string][]model.App, 0)
+	base := make([]templates.Application, 0)
+	for _, app := range appSpec.Apps {
+		application, err := task.LoadApplicationFrom(app.Name, apps)
+		if err != nil {
+			return nil, err
+		}
+		names, err := task.TemplateEnvironments(application, registry)
+		if err != nil {
+			return nil, err
+		}
+		for _, env := range names {
+			environments[env] = append(environments[env], app)
+		}
+		values, err := task.ProcessApplicationFrom(&app, appSpec.ReleaseName, appSpec.Namespace, "", apps, provider)
+		if err != nil {
+			return nil, err
+		}
+		logWarnings(values.Warnings)
+		base = append(base, *values)
+	}
+
+	names := make([]string, 0, len(environments))
+	for env := range environments {
+		names = append(names, env)
+	}
+	sort.Strings(names)
+	overlays := make([]templates.ReleaseTemplate, 0)
+	for _, env := range names {
+		overlay := templates.ReleaseTemplate{
+			Namespace:   appSpec.Namespace,
+			ReleaseName: appSpec.ReleaseName,
+			Environment: env,
+			Application: make([]templates.Application, 0),
+		}
+		for _, app := range environments[env] {
+			values, err := task.ProcessApplicationFrom(&app, appSpec.ReleaseName, appSpec.Namespace, env, apps, provider)
+			if err != nil {
+				return nil, err
+			}
+			overlay.Application = append(overlay.Application, *values)
+		}
+		overlays = append(overlays, overlay)
+	}
+
+	releaseTemplate := templates.ReleaseTemplate{
+		Namespace:   appSpec.Namespace,
+		ReleaseName: appSpec.ReleaseName,
+		Application: base,
+	}
+	return templates.RunKustomize(&releaseTemplate, overlays, outputDir)
+}
@@ -0,0 +1,31 @@
+package entry
+
+import (
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestKustomizeGenerator(t *testing.T) {
+	outputDir := "../tmp-kustomize"
+	defer os.RemoveAll(outputDir)
+	appDir := "../sample-manifest/user/apps"
+	resourceDir := "../sample-manifest/provider"
+	summary, err := KustomizeGenerator(GetAppSpec(), appDir, resourceDir, outputDir)
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(summary.Items))
+
+	base, err := ioutil.ReadFile(outputDir + "/release-2/base/kustomization.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(base), "- busybox/busybox-deployment.yaml"))
+	for _, env := range []string{"test", "lab", "prod"} {
+		overlay, err := ioutil.ReadFile(outputDir + "/release-2/overlays/" + env + "/kustomization.yaml")
+		test.Null(t, err)
+		test.EqualTo(t, true, strings.Contains(string(overlay), "- path: busybox-patch.yaml"))
+	}
+	patch, err := ioutil.ReadFile(outputDir + "/release-2/overlays/prod/busybox-patch.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(patch), "replicas: 2"))
+}
@@ -3,7 +3,11 @@ package functions
 import (
 	"errors"
 	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
 	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+	"io/fs"
 	"io/ioutil"
 )
 
@@ -19,6 +23,36 @@ func UnmarshalFile(file string, t interface{}) error {
 	return nil
 }
 
+//UnmarshalManifest reads the manifest of the given kind from src.
+//A missing file is a *model.ManifestNotFoundError still matching fs.ErrNotExist, invalid yaml or an unknown field
+//of a source not decoded leniently, see source.Lenient, a *model.ManifestParseError.
+//The returned manifest keeps the raw content to locate values in later errors
+func UnmarshalManifest(src source.ManifestSource, kind string, file string, t interface{}) (*model.Manifest, error) {
+	content, err := src.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &model.ManifestNotFoundError{
+			Location: model.Location{Kind: kind, File: file},
+			Err:      err,
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[file]: %s, [error]: %w", file, err)
+	}
+	err = yaml.Unmarshal(content, t)
+	if err != nil {
+		return nil, model.NewParseError(kind, file, err)
+	}
+	if !source.IsLenient(src) {
+		var document yaml3.Node
+		if yaml3.Unmarshal(content, &document) == nil {
+			if fields := UnknownFields(&document, t); len(fields) > 0 {
+				return nil, model.NewUnknownFieldsError(kind, file, fields)
+			}
+		}
+	}
+	return &model.Manifest{Kind: kind, File: file, Content: content}, nil
+}
+
 func ReadFile(file string) (*[]byte, error) {
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
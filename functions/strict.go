@@ -0,0 +1,187 @@
+package functions
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+//UnknownFields lists the keys of the yaml document that are not fields of t, each with the closest known field
+func UnknownFields(document *yaml3.Node, t interface{}) []model.UnknownField {
+	fields := make([]model.UnknownField, 0)
+	walkFields(document, reflect.TypeOf(t), &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+//Suggest returns the candidate closest to value, or empty when none is close enough to be a typo
+func Suggest(value string, candidates []string) string {
+	suggestion := ""
+	best := len(value)/3 + 1
+	if best > 3 {
+		best = 3
+	}
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(value), strings.ToLower(candidate))
+		if distance < best {
+			suggestion = candidate
+			best = distance
+		}
+	}
+	return suggestion
+}
+
+var unmarshaler = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+//decodedTypes registers the types a custom unmarshaler decodes a mapping into, eg: (*model.Probe).UnmarshalYAML
+//decodes a mapping into an alias of model.Probe. The keys of other types with a custom unmarshaler are not checked
+var decodedTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(model.Probe{}): reflect.TypeOf(model.Probe{}),
+}
+
+//walkFields checks the keys of every mapping of node against the yaml fields of the type it is decoded into
+func walkFields(node *yaml3.Node, t reflect.Type, fields *[]model.UnknownField) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml3.DocumentNode {
+		for _, content := range node.Content {
+			walkFields(content, t, fields)
+		}
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshaler) {
+		decoded, ok := decodedTypes[t]
+		if !ok || node.Kind != yaml3.MappingNode {
+			return
+		}
+		walkStruct(node, decoded, fields)
+		return
+	}
+	switch {
+	case node.Kind == yaml3.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, item := range node.Content {
+			walkFields(item, t.Elem(), fields)
+		}
+	case node.Kind == yaml3.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "<<" {
+				walkFields(node.Content[i+1], t, fields)
+				continue
+			}
+			walkFields(node.Content[i+1], t.Elem(), fields)
+		}
+	case node.Kind == yaml3.MappingNode && t.Kind() == reflect.Struct:
+		walkStruct(node, t, fields)
+	}
+}
+
+//walkStruct checks the keys of a mapping decoded into the struct t, merged mappings included
+func walkStruct(node *yaml3.Node, t reflect.Type, fields *[]model.UnknownField) {
+	known := make(map[string]reflect.Type)
+	names := make([]string, 0, t.NumField())
+	if !structFields(t, known, &names) {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			merged := value
+			if merged.Kind == yaml3.AliasNode && merged.Alias != nil {
+				merged = merged.Alias
+			}
+			if merged.Kind == yaml3.SequenceNode {
+				for _, item := range merged.Content {
+					walkFields(item, t, fields)
+				}
+				continue
+			}
+			walkFields(merged, t, fields)
+			continue
+		}
+		fieldType, ok := known[key.Value]
+		if !ok {
+			*fields = append(*fields, model.UnknownField{
+				Line:       key.Line,
+				Field:      key.Value,
+				Type:       t.String(),
+				Suggestion: Suggest(key.Value, names),
+			})
+			continue
+		}
+		walkFields(value, fieldType, fields)
+	}
+}
+
+//structFields maps the yaml field names of the struct t to their types and lists them in order, inlined structs
+//included. It returns false when t inlines a map, which accepts any key
+func structFields(t reflect.Type, known map[string]reflect.Type, names *[]string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		inline := false
+		for _, flag := range tag[1:] {
+			inline = inline || flag == "inline"
+		}
+		if inline {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Map || !structFields(fieldType, known, names) {
+				return false
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		known[name] = field.Type
+		*names = append(*names, name)
+	}
+	return true
+}
+
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
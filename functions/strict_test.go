@@ -0,0 +1,65 @@
+package functions
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestUnmarshalManifestRejectsUnknownFields(t *testing.T) {
+	src := source.Map{
+		"infrastructure/es.yaml": "kind: Infrastructure\nspec:\n  template:\n    - nane: alpha\n",
+	}
+	_, err := UnmarshalManifest(src, model.KindInfrastructure, "infrastructure/es.yaml", &model.Infrastructure{})
+	var parseErr *model.ManifestParseError
+	test.EqualTo(t, true, errors.As(err, &parseErr))
+	test.EqualTo(t, 4, parseErr.Line)
+	test.EqualTo(t, 1, len(parseErr.Fields))
+	test.EqualTo(t, "name", parseErr.Fields[0].Suggestion)
+	test.EqualTo(t, "line 4: unknown field nane of model.InfraTemplate, did you mean name?", parseErr.Message())
+
+	_, err = UnmarshalManifest(source.Lenient(src), model.KindInfrastructure, "infrastructure/es.yaml", &model.Infrastructure{})
+	test.Null(t, err)
+}
+
+func TestSuggest(t *testing.T) {
+	test.EqualTo(t, "Job", Suggest("jobs", []string{"Deployment", "Job"}))
+	test.EqualTo(t, "resource-limit-strategy", Suggest("resource-limits-strategy", []string{"cpu", "resource-limit-strategy"}))
+	test.EqualTo(t, "", Suggest("schedule", []string{"Deployment", "Job"}))
+}
+
+func TestUnmarshalManifestDecodesProbes(t *testing.T) {
+	src := source.Map{
+		"api.yaml": "name: api\nliveness_probe: /health\nreadiness_probe:\n  tcpSocket:\n    port: http\n  periodSecond: 5\n",
+	}
+	application := &model.Application{}
+	_, err := UnmarshalManifest(src, model.KindApplication, "api.yaml", application)
+	var parseErr *model.ManifestParseError
+	test.EqualTo(t, true, errors.As(err, &parseErr))
+	test.EqualTo(t, "periodSeconds", parseErr.Fields[0].Suggestion)
+
+	src["api.yaml"] = "name: api\nliveness_probe: /health\nreadiness_probe:\n  tcpSocket:\n    port: http\n  periodSeconds: 5\n"
+	_, err = UnmarshalManifest(src, model.KindApplication, "api.yaml", application)
+	test.Null(t, err)
+	test.EqualTo(t, "/health", application.LivenessProbe.HttpGet.Path)
+	test.EqualTo(t, 30, application.LivenessProbe.InitialDelaySeconds)
+	test.EqualTo(t, "http", application.ReadinessProbe.TcpSocket.Port)
+	test.EqualTo(t, 5, application.ReadinessProbe.PeriodSeconds)
+}
+
+func TestUnknownFieldsWalksNestedManifests(t *testing.T) {
+	src := source.Map{
+		"api.yaml": "name: api\ntemplate:\n  - name: prod\n    storage:\n      data:\n        sise: 1Gi\n" +
+			"readiness_probe: &probe\n  tcpSocket: {port: http}\nliveness_probe:\n  <<: *probe\n  periodSecond: 5\n",
+	}
+	_, err := UnmarshalManifest(src, model.KindApplication, "api.yaml", &model.Application{})
+	var parseErr *model.ManifestParseError
+	test.EqualTo(t, true, errors.As(err, &parseErr))
+	test.EqualTo(t, 2, len(parseErr.Fields))
+	test.EqualTo(t, "line 6: unknown field sise of model.Storage, did you mean size?", parseErr.Fields[0].String())
+	test.EqualTo(t, 11, parseErr.Fields[1].Line)
+	test.EqualTo(t, "model.Probe", parseErr.Fields[1].Type)
+	test.EqualTo(t, 6, parseErr.Line)
+}
@@ -1,5 +1,8 @@
 module github.com/kube-sailmaker/template-gen
 
-go 1.14
+go 1.16
 
-require gopkg.in/yaml.v2 v2.2.8
+require (
+	gopkg.in/yaml.v2 v2.2.8
+	gopkg.in/yaml.v3 v3.0.1
+)
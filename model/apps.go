@@ -9,9 +9,10 @@ type Args struct {
 
 type Application struct {
 	Name                    string            `yaml:"name"`
-	Kind                    string            `yaml:"kind"`
-	LivenessProbe           string            `yaml:"liveness_probe"`
-	ReadinessProbe          string            `yaml:"readiness_probe"`
+	Kind                    string            `yaml:"kind" enum:"Deployment,Job,CronJob,StatefulSet"`
+	LivenessProbe           *Probe            `yaml:"liveness_probe" scalar:"true"`
+	ReadinessProbe          *Probe            `yaml:"readiness_probe" scalar:"true"`
+	StartupProbe            *Probe            `yaml:"startup_probe" scalar:"true"`
 	Annotations             map[string]string `yaml:"annotations"`
 	Resources               []string          `yaml:"resources"`
 	Capabilities            []string          `yaml:"capabilities"`
@@ -21,16 +22,81 @@ type Application struct {
 	BackoffLimit            int               `yaml:"backoffLimit"`
 	ActiveDeadLine          int               `yaml:"activeDeadlineSeconds"`
 	TTLSecondsAfterFinished int               `yaml:"ttlSecondsAfterFinished"`
-	RestartPolicy           string            `yaml:"restartPolicy"`
+	RestartPolicy           string            `yaml:"restartPolicy" enum:"Always,OnFailure,Never"`
+	Schedule                string            `yaml:"schedule"`
+	TimeZone                string            `yaml:"timeZone"`
+	ConcurrencyPolicy       string            `yaml:"concurrencyPolicy" enum:"Allow,Forbid,Replace"`
+	StartingDeadline        int               `yaml:"startingDeadlineSeconds"`
+	SuccessfulJobsHistory   *int              `yaml:"successfulJobsHistoryLimit"`
+	FailedJobsHistory       *int              `yaml:"failedJobsHistoryLimit"`
+	PodManagementPolicy     string            `yaml:"podManagementPolicy" enum:"OrderedReady,Parallel"`
+	UpdateStrategy          *UpdateStrategy   `yaml:"updateStrategy"`
+	VolumeClaims            []VolumeClaim     `yaml:"volumeClaimTemplates"`
+	ConfigMap               bool              `yaml:"configMap"`
+	ConfigPrefix            string            `yaml:"configPrefix"`
+	Placement               *Placement        `yaml:"placement"`
+	Image                   *ImageSpec        `yaml:"image"`
 }
 
+//ImageSpec locates the image of an app, the repository defaults to the app name and the registry to docker hub.
+//Mirrors replace the registry in the environments they are listed for, eg: prod: registry.prod.internal
+type ImageSpec struct {
+	Registry    string            `yaml:"registry"`
+	Repository  string            `yaml:"repository"`
+	Digest      string            `yaml:"digest"`
+	PullPolicy  string            `yaml:"pullPolicy" enum:"Always,IfNotPresent,Never"`
+	PullSecrets []string          `yaml:"pullSecrets"`
+	Mirrors     map[string]string `yaml:"mirrors"`
+}
+
+//ServiceSpec exposes the ports of the app, port is the container port of the default http port
+//served on port 80 when no ports are listed. A headless service has no cluster ip
 type ServiceSpec struct {
-	Enabled bool `yaml:"enabled"`
-	Port    int  `yaml:"port"`
+	Enabled     bool              `yaml:"enabled"`
+	Port        int               `yaml:"port"`
+	Type        string            `yaml:"type" enum:"ClusterIP,NodePort,LoadBalancer,headless"`
+	Annotations map[string]string `yaml:"annotations"`
+	Ports       []ServicePort     `yaml:"ports"`
+}
+
+//ServicePort is a named container port, the service port defaults to the container port
+type ServicePort struct {
+	Name          string `yaml:"name"`
+	Protocol      string `yaml:"protocol" enum:"TCP,UDP,SCTP"`
+	Port          int    `yaml:"port"`
+	ContainerPort int    `yaml:"containerPort"`
+	AppProtocol   string `yaml:"appProtocol"`
+	NodePort      int    `yaml:"nodePort"`
+}
+
+//UpdateStrategy replaces the pods of a StatefulSet, a partition keeps the pods ordered below it on the
+//previous revision
+type UpdateStrategy struct {
+	Type      string `yaml:"type" enum:"RollingUpdate,OnDelete"`
+	Partition int    `yaml:"partition"`
+}
+
+//VolumeClaim is a persistent volume claimed by every pod of a StatefulSet and mounted at mountPath,
+//the storage of an app template overrides its size and storage class in an environment
+type VolumeClaim struct {
+	Name         string   `yaml:"name"`
+	MountPath    string   `yaml:"mountPath"`
+	AccessModes  []string `yaml:"accessModes"`
+	StorageClass string   `yaml:"storageClass"`
+	Size         string   `yaml:"size"`
+}
+
+//Storage of a volume claim in an environment
+type Storage struct {
+	StorageClass string `yaml:"storageClass"`
+	Size         string `yaml:"size"`
 }
 
 type AppTemplate struct {
-	Name    string            `yaml:"name"`
-	Replica int               `yaml:"replica"`
-	Config  map[string]string `yaml:"config"`
+	Name      string             `yaml:"name"`
+	Replica   int                `yaml:"replica"`
+	Extends   string             `yaml:"extends"`
+	Config    map[string]string  `yaml:"config"`
+	Placement *Placement         `yaml:"placement"`
+	Storage   map[string]Storage `yaml:"storage"`
 }
@@ -5,15 +5,18 @@ import (
 )
 
 type AppSpec struct {
-	Namespace   string `json:"namespace"`
-	ReleaseName string `json:"release-name"`
-	Environment string `json:"environment"`
-	Apps        []App  `json:"apps"`
+	Namespace    string `json:"namespace" yaml:"namespace"`
+	ReleaseName  string `json:"release-name" yaml:"release-name"`
+	Environment  string `json:"environment" yaml:"environment"`
+	ChartVersion string `json:"chart-version" yaml:"chart-version"`
+	Apps         []App  `json:"apps" yaml:"apps"`
 }
 
+//App is an app of a release, version is the image tag and digest pins the image of the release
 type App struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Digest  string `json:"digest,omitempty" yaml:"digest"`
 }
 
 func (as *AppSpec) Validate() error {
@@ -35,9 +38,17 @@ func (as *AppSpec) Normalise() {
 }
 
 type DeploymentItem struct {
-	Name string `json:"name"`
-	Kind string `json:"kind"`
-	Path string `json:"path"`
+	Name    string        `json:"name"`
+	Kind    string        `json:"kind"`
+	Path    string        `json:"path"`
+	Sources []ValueSource `json:"sources,omitempty"`
+}
+
+//ValueSource records which mixin or app template supplied the final value of a field
+type ValueSource struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
 }
 
 type DeploymentItemSummary struct {
@@ -0,0 +1,47 @@
+package model
+
+type Capability struct {
+	ApiVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind" enum:"Capability"`
+	Metadata   map[string]string `yaml:"metadata"`
+	Spec       CapabilitySpec    `yaml:"spec"`
+}
+
+type CapabilitySpec struct {
+	Annotations map[string]string `yaml:"annotations"`
+	Env         map[string]string `yaml:"env"`
+	Volumes     []Volume          `yaml:"volumes"`
+	Sidecars    []Sidecar         `yaml:"sidecars"`
+	Rules       []PolicyRule      `yaml:"rules"`
+}
+
+//Volume is mounted into the application container when MountPath is set,
+//secret and configMap select the source, otherwise an emptyDir is used
+type Volume struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly"`
+	Secret    string `yaml:"secret"`
+	ConfigMap string `yaml:"configMap"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly"`
+}
+
+type Sidecar struct {
+	Name         string            `yaml:"name"`
+	Image        string            `yaml:"image"`
+	Args         []string          `yaml:"args"`
+	Env          map[string]string `yaml:"env"`
+	Port         int               `yaml:"port"`
+	VolumeMounts []VolumeMount     `yaml:"volumeMounts"`
+}
+
+type PolicyRule struct {
+	ApiGroups []string `yaml:"apiGroups"`
+	Resources []string `yaml:"resources"`
+	Verbs     []string `yaml:"verbs"`
+}
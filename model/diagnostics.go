@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Severity of a diagnostic, strict validation promotes warnings to errors
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+//Diagnostic is one problem found while resolving a release
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Location
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+//NewDiagnostic turns err into a diagnostic, the location of a LocatedError is kept
+func NewDiagnostic(severity string, err error) Diagnostic {
+	diagnostic := Diagnostic{
+		Severity: severity,
+		Message:  err.Error(),
+		Err:      err,
+	}
+	var located LocatedError
+	if errors.As(err, &located) {
+		diagnostic.Location = *located.Locate()
+		diagnostic.Message = located.Message()
+	}
+	return diagnostic
+}
+
+//Warning creates a warning diagnostic at location
+func Warning(location Location, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{
+		Severity: SeverityWarning,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (d Diagnostic) String() string {
+	prefix := d.Location.String()
+	if prefix == "" {
+		return fmt.Sprintf("[%s]: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s, [%s]: %s", prefix, d.Severity, d.Message)
+}
+
+//Diagnostics is the report of a validation pass, as an error it lists every error of the report
+type Diagnostics []Diagnostic
+
+//HasErrors is true when the report holds at least one error
+func (d Diagnostics) HasErrors() bool {
+	return len(d.Errors()) > 0
+}
+
+//Errors returns the diagnostics of error severity
+func (d Diagnostics) Errors() Diagnostics {
+	return d.filter(SeverityError)
+}
+
+//Warnings returns the diagnostics of warning severity
+func (d Diagnostics) Warnings() Diagnostics {
+	return d.filter(SeverityWarning)
+}
+
+//Strict returns a copy of the report with every warning promoted to an error
+func (d Diagnostics) Strict() Diagnostics {
+	strict := make(Diagnostics, len(d))
+	for i, diagnostic := range d {
+		diagnostic.Severity = SeverityError
+		strict[i] = diagnostic
+	}
+	return strict
+}
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, 0, len(d))
+	for _, diagnostic := range d.Errors() {
+		lines = append(lines, diagnostic.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//As gives errors.As access to the typed errors of the report, it finds the first error of the report matching target
+func (d Diagnostics) As(target interface{}) bool {
+	for _, diagnostic := range d.Errors() {
+		if diagnostic.Err != nil && errors.As(diagnostic.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+//Is reports whether an error of the report matches target, eg: fs.ErrNotExist
+func (d Diagnostics) Is(target error) bool {
+	for _, diagnostic := range d.Errors() {
+		if diagnostic.Err != nil && errors.Is(diagnostic.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d Diagnostics) filter(severity string) Diagnostics {
+	filtered := make(Diagnostics, 0)
+	for _, diagnostic := range d {
+		if diagnostic.Severity == severity {
+			filtered = append(filtered, diagnostic)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,15 @@
+package model
+
+//EnvironmentRegistry declares the environments of a provider, listed in promotion order
+type EnvironmentRegistry struct {
+	Environments []Environment `yaml:"environments"`
+}
+
+//Environment names an environment of the release spec and app templates, the aliases accepted for it,
+//and the resource and infrastructure templates it uses, both default to the name
+type Environment struct {
+	Name     string   `yaml:"name"`
+	Aliases  []string `yaml:"aliases"`
+	Resource string   `yaml:"resource"`
+	Infra    string   `yaml:"infrastructure"`
+}
@@ -0,0 +1,348 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Kind of the manifest an error refers to
+const (
+	KindApplication    = "Application"
+	KindResource       = "Resource"
+	KindInfrastructure = "Infrastructure"
+	KindMixin          = "Mixin"
+	KindCapability     = "Capability"
+	KindSizes          = "Sizes"
+	KindEnvironments   = "Environments"
+	KindPlacement      = "Placement"
+)
+
+//Location points at the manifest, and when known the line and column, an error was raised for
+type Location struct {
+	App    string `json:"app,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+//LocatedError is implemented by every manifest error, errors.As(err, &located) gives access to its location.
+//Message describes the problem without the location
+type LocatedError interface {
+	error
+	Locate() *Location
+	Message() string
+}
+
+func (l *Location) Locate() *Location {
+	return l
+}
+
+func (l *Location) String() string {
+	fields := make([]string, 0)
+	if l.App != "" {
+		fields = append(fields, fmt.Sprintf("[app]: %s", l.App))
+	}
+	if l.File != "" {
+		file := l.File
+		if l.Line > 0 {
+			file = fmt.Sprintf("%s:%d", file, l.Line)
+			if l.Column > 0 {
+				file = fmt.Sprintf("%s:%d", file, l.Column)
+			}
+		}
+		fields = append(fields, fmt.Sprintf("[file]: %s", file))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func (l *Location) format(msg string) string {
+	prefix := l.String()
+	if prefix == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s, [%s]: %s", prefix, SeverityError, msg)
+}
+
+//UnknownField is a field of a manifest that is not part of its schema, eg: a misspelled key.
+//Suggestion is the closest known field of the same type
+type UnknownField struct {
+	Line       int    `json:"line"`
+	Field      string `json:"field"`
+	Type       string `json:"type"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func (f UnknownField) String() string {
+	msg := fmt.Sprintf("line %d: unknown field %s of %s", f.Line, f.Field, f.Type)
+	if f.Suggestion != "" {
+		msg = fmt.Sprintf("%s, did you mean %s?", msg, f.Suggestion)
+	}
+	return msg
+}
+
+//ManifestNotFoundError is raised when a referenced manifest does not exist
+type ManifestNotFoundError struct {
+	Location
+	Err error
+}
+
+func (e *ManifestNotFoundError) Message() string {
+	return fmt.Sprintf("%s manifest not found", strings.ToLower(e.Kind))
+}
+
+func (e *ManifestNotFoundError) Error() string {
+	return e.format(e.Message())
+}
+
+func (e *ManifestNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+//ManifestParseError is raised when a manifest is not valid yaml or does not match its schema,
+//Fields lists the unknown fields rejected by strict decoding
+type ManifestParseError struct {
+	Location
+	Fields []UnknownField
+	Err    error
+}
+
+func (e *ManifestParseError) Message() string {
+	if len(e.Fields) == 0 {
+		return e.Err.Error()
+	}
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.String())
+	}
+	return strings.Join(fields, "; ")
+}
+
+func (e *ManifestParseError) Error() string {
+	return e.format(e.Message())
+}
+
+func (e *ManifestParseError) Unwrap() error {
+	return e.Err
+}
+
+//UnknownEnvironmentError is raised when an app has no template for the environment of the release
+type UnknownEnvironmentError struct {
+	Location
+	Environment string
+}
+
+func (e *UnknownEnvironmentError) Message() string {
+	return fmt.Sprintf("unknown environment %s", e.Environment)
+}
+
+func (e *UnknownEnvironmentError) Error() string {
+	return e.format(e.Message())
+}
+
+//InvalidReferenceError is raised for a malformed or dangling reference to another manifest,
+//eg: a resource without template type or an unknown capability
+type InvalidReferenceError struct {
+	Location
+	Reference string
+	Reason    string
+	Err       error
+}
+
+func (e *InvalidReferenceError) Message() string {
+	return fmt.Sprintf("invalid reference %s, %s", e.Reference, e.Reason)
+}
+
+func (e *InvalidReferenceError) Error() string {
+	return e.format(e.Message())
+}
+
+func (e *InvalidReferenceError) Unwrap() error {
+	return e.Err
+}
+
+//InvalidValueError is raised for a field holding a value outside of what it accepts
+type InvalidValueError struct {
+	Location
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *InvalidValueError) Message() string {
+	return fmt.Sprintf("invalid %s %s, %s", e.Field, e.Value, e.Reason)
+}
+
+func (e *InvalidValueError) Error() string {
+	return e.format(e.Message())
+}
+
+//RenderError is raised when a template cannot be rendered or written
+type RenderError struct {
+	Location
+	Template string
+	Err      error
+}
+
+func (e *RenderError) Message() string {
+	return fmt.Sprintf("rendering %s, %v", e.Template, e.Err)
+}
+
+func (e *RenderError) Error() string {
+	return e.format(e.Message())
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+//Manifest is the raw content of a decoded manifest, kept to locate values when reporting errors
+type Manifest struct {
+	Kind    string
+	File    string
+	Content []byte
+}
+
+//Locate returns the location of the first scalar of the manifest equal to value, a key or a value,
+//the line is left empty when the value cannot be found
+func (m *Manifest) Locate(app string, value string) Location {
+	return m.locate(app, func(key *yaml.Node, node *yaml.Node) *yaml.Node {
+		if key != nil && key.Value == value {
+			return key
+		}
+		if node.Kind == yaml.ScalarNode && node.Value == value {
+			return node
+		}
+		return nil
+	})
+}
+
+//LocateField returns the location of the first key field holding value, eg: cpu: c9,
+//the line is left empty when no such field can be found
+func (m *Manifest) LocateField(app string, field string, value string) Location {
+	return m.locate(app, func(key *yaml.Node, node *yaml.Node) *yaml.Node {
+		if key != nil && key.Value == field && node.Kind == yaml.ScalarNode && node.Value == value {
+			return key
+		}
+		return nil
+	})
+}
+
+//LocatePath returns the location of the key or item at path in the given document of the manifest,
+//eg: template, 0, config for the config of the first app template
+func (m *Manifest) LocatePath(app string, document int, path ...string) Location {
+	location := m.locate(app, nil)
+	documents := m.documents()
+	if document >= len(documents) || len(documents[document].Content) == 0 || len(path) == 0 {
+		return location
+	}
+	node := documents[document].Content[0]
+	var found *yaml.Node
+	for _, segment := range path {
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		found = nil
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					found, node = node.Content[i], node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				found, node = node.Content[index], node.Content[index]
+			}
+		}
+		if found == nil {
+			return location
+		}
+	}
+	location.Line = found.Line
+	location.Column = found.Column
+	return location
+}
+
+//locate returns the location of the first node of the manifest in document order matched by match,
+//which is given every value with its key, nil for items and documents
+func (m *Manifest) locate(app string, match func(key *yaml.Node, node *yaml.Node) *yaml.Node) Location {
+	location := Location{App: app}
+	if m == nil {
+		return location
+	}
+	location.Kind = m.Kind
+	location.File = m.File
+	if match == nil {
+		return location
+	}
+	for _, document := range m.documents() {
+		if node := find(document, nil, match); node != nil {
+			location.Line = node.Line
+			location.Column = node.Column
+			break
+		}
+	}
+	return location
+}
+
+func find(node *yaml.Node, key *yaml.Node, match func(key *yaml.Node, node *yaml.Node) *yaml.Node) *yaml.Node {
+	if found := match(key, node); found != nil {
+		return found
+	}
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, item := range node.Content {
+			if found := find(item, nil, match); found != nil {
+				return found
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if found := find(node.Content[i+1], node.Content[i], match); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+//documents decodes the yaml documents of the manifest, up to the first invalid one
+func (m *Manifest) documents() []*yaml.Node {
+	decoder := yaml.NewDecoder(bytes.NewReader(m.Content))
+	documents := make([]*yaml.Node, 0)
+	for {
+		document := &yaml.Node{}
+		if decoder.Decode(document) != nil {
+			return documents
+		}
+		documents = append(documents, document)
+	}
+}
+
+var lineNumber = regexp.MustCompile(`line (\d+)`)
+
+//NewUnknownFieldsError reports the unknown fields of a manifest, located at the first of them
+func NewUnknownFieldsError(kind string, file string, fields []UnknownField) *ManifestParseError {
+	return &ManifestParseError{
+		Location: Location{Kind: kind, File: file, Line: fields[0].Line},
+		Fields:   fields,
+	}
+}
+
+//NewParseError wraps a yaml decoding error, the line reported by the decoder is kept in the location
+func NewParseError(kind string, file string, err error) *ManifestParseError {
+	parseErr := &ManifestParseError{
+		Location: Location{Kind: kind, File: file},
+		Err:      err,
+	}
+	if match := lineNumber.FindStringSubmatch(err.Error()); match != nil {
+		parseErr.Line, _ = strconv.Atoi(match[1])
+	}
+	return parseErr
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestManifestLocate(t *testing.T) {
+	manifest := &Manifest{
+		Kind:    KindApplication,
+		File:    "busybox.yaml",
+		Content: []byte("name: busybox\nmixins:\n  - tiny/small\n  - resource-spec/tiny\n"),
+	}
+	location := manifest.Locate("busybox", "resource-spec/tiny")
+	test.EqualTo(t, 4, location.Line)
+	test.EqualTo(t, 5, location.Column)
+	test.EqualTo(t, "[app]: busybox, [file]: busybox.yaml:4:5", location.String())
+
+	location = manifest.Locate("busybox", "tiny")
+	test.EqualTo(t, 0, location.Line)
+}
+
+func TestManifestLocateUsesYamlPositions(t *testing.T) {
+	manifest := &Manifest{
+		Kind: KindApplication,
+		File: "api.yaml",
+		Content: []byte("name: api\n#cpu: c9 before the fix\ntemplate:\n  - name: test\n    config:\n      cpu: c1\n" +
+			"  - name: prod\n    config:\n      cpu: c9\n"),
+	}
+	location := manifest.LocateField("api", "cpu", "c9")
+	test.EqualTo(t, 9, location.Line)
+	test.EqualTo(t, 7, location.Column)
+
+	location = manifest.LocatePath("api", 0, "template", "1", "name")
+	test.EqualTo(t, 7, location.Line)
+	test.EqualTo(t, 5, location.Column)
+
+	location = manifest.LocatePath("api", 0, "template", "2")
+	test.EqualTo(t, 0, location.Line)
+	test.EqualTo(t, "api.yaml", location.File)
+
+	location = manifest.Locate("api", "prod")
+	test.EqualTo(t, 7, location.Line)
+	test.EqualTo(t, 11, location.Column)
+}
+
+func TestNewParseError(t *testing.T) {
+	err := NewParseError(KindMixin, "mixins/java.yaml", errors.New("yaml: line 7: did not find expected key"))
+	test.EqualTo(t, 7, err.Line)
+
+	var located LocatedError
+	test.EqualTo(t, true, errors.As(error(err), &located))
+	test.EqualTo(t, "mixins/java.yaml", located.Locate().File)
+	test.EqualTo(t, "[file]: mixins/java.yaml:7, [error]: yaml: line 7: did not find expected key", err.Error())
+}
@@ -2,7 +2,7 @@ package model
 
 type Infrastructure struct {
 	ApiVersion string            `yaml:"apiVersion"`
-	Kind       string            `yaml:"kind"`
+	Kind       string            `yaml:"kind" enum:"Infrastructure"`
 	Metadata   map[string]string `yaml:"metadata"`
 	Spec       InfraSpec         `yaml:"spec"`
 }
@@ -11,7 +11,10 @@ type InfraSpec struct {
 	Template []InfraTemplate `yaml:"template"`
 }
 
+//InfraTemplate holds the attributes of an infrastructure for one environment,
+//attributes named in secrets are rendered into a Secret instead of plain env vars
 type InfraTemplate struct {
 	Name       string            `yaml:"name"`
 	Attributes map[string]string `yaml:"attributes"`
+	Secrets    []string          `yaml:"secrets"`
 }
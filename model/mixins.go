@@ -6,11 +6,17 @@ type MixinList struct {
 
 type Mixin struct {
 	Name             string            `yaml:"name"`
+	Salience         int               `yaml:"salience"`
+	ListPolicy       string            `yaml:"list-policy" enum:"replace,append"`
 	Cpu              string            `yaml:"cpu"`
 	Memory           string            `yaml:"memory"`
 	Replicas         string            `yaml:"replicas"`
-	ResourceStrategy string            `yaml:"resource-limit-strategy"`
-	Env              map[string]string `yaml:env`
+	ResourceStrategy string            `yaml:"resource-limit-strategy" enum:"half,exact,none"`
+	Env              map[string]string `yaml:"env"`
 	Cmd              []string          `yaml:"cmd"`
 	Entrypoint       []string          `yaml:"entrypoint"`
+	LivenessProbe    *Probe            `yaml:"liveness-probe" scalar:"true"`
+	ReadinessProbe   *Probe            `yaml:"readiness-probe" scalar:"true"`
+	StartupProbe     *Probe            `yaml:"startup-probe" scalar:"true"`
+	Source           string            `yaml:"-"`
 }
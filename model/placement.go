@@ -0,0 +1,39 @@
+package model
+
+//Placement constrains the nodes the pods of an app are scheduled on.
+//Affinity takes the kubernetes affinity as is, eg: nodeAffinity, podAntiAffinity
+type Placement struct {
+	NodeSelector              map[string]string          `yaml:"nodeSelector,omitempty"`
+	Affinity                  map[string]interface{}     `yaml:"affinity,omitempty"`
+	Tolerations               []Toleration               `yaml:"tolerations,omitempty"`
+	TopologySpreadConstraints []TopologySpreadConstraint `yaml:"topologySpreadConstraints,omitempty"`
+}
+
+type Toleration struct {
+	Key               string `yaml:"key,omitempty"`
+	Operator          string `yaml:"operator,omitempty" enum:"Exists,Equal"`
+	Value             string `yaml:"value,omitempty"`
+	Effect            string `yaml:"effect,omitempty" enum:"NoSchedule,PreferNoSchedule,NoExecute"`
+	TolerationSeconds int    `yaml:"tolerationSeconds,omitempty"`
+}
+
+//TopologySpreadConstraint without labelSelector spreads the pods of the app
+type TopologySpreadConstraint struct {
+	MaxSkew           int                    `yaml:"maxSkew"`
+	TopologyKey       string                 `yaml:"topologyKey"`
+	WhenUnsatisfiable string                 `yaml:"whenUnsatisfiable" enum:"DoNotSchedule,ScheduleAnyway"`
+	MinDomains        int                    `yaml:"minDomains,omitempty"`
+	LabelSelector     map[string]interface{} `yaml:"labelSelector,omitempty"`
+}
+
+//PlacementPolicies of the provider are applied to the apps of their environments, to every environment when none
+//are listed, before the placement of the app manifest
+type PlacementPolicies struct {
+	Policies []PlacementPolicy `yaml:"policies"`
+}
+
+type PlacementPolicy struct {
+	Name         string    `yaml:"name"`
+	Environments []string  `yaml:"environments"`
+	Placement    Placement `yaml:"placement"`
+}
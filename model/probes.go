@@ -0,0 +1,68 @@
+package model
+
+//Probe checks a container with one of httpGet, tcpSocket, exec or grpc.
+//A scalar probe is the path of an httpGet probe with a 30 seconds initial delay and a 100 seconds timeout
+type Probe struct {
+	HttpGet                       *HttpGetAction   `yaml:"httpGet"`
+	TcpSocket                     *TcpSocketAction `yaml:"tcpSocket"`
+	Exec                          *ExecAction      `yaml:"exec"`
+	Grpc                          *GrpcAction      `yaml:"grpc"`
+	InitialDelaySeconds           int              `yaml:"initialDelaySeconds"`
+	PeriodSeconds                 int              `yaml:"periodSeconds"`
+	TimeoutSeconds                int              `yaml:"timeoutSeconds"`
+	SuccessThreshold              int              `yaml:"successThreshold"`
+	FailureThreshold              int              `yaml:"failureThreshold"`
+	TerminationGracePeriodSeconds int              `yaml:"terminationGracePeriodSeconds"`
+}
+
+//HttpGetAction port is a port name or number, it defaults to the probe port of the app
+type HttpGetAction struct {
+	Path        string       `yaml:"path"`
+	Port        string       `yaml:"port"`
+	Scheme      string       `yaml:"scheme" enum:"HTTP,HTTPS"`
+	HttpHeaders []HttpHeader `yaml:"httpHeaders"`
+}
+
+type HttpHeader struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+//TcpSocketAction port is a port name or number, it defaults to the probe port of the app
+type TcpSocketAction struct {
+	Port string `yaml:"port"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type GrpcAction struct {
+	Port    int    `yaml:"port"`
+	Service string `yaml:"service"`
+}
+
+func (p *Probe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*p = Probe{
+			HttpGet:             &HttpGetAction{Path: path},
+			InitialDelaySeconds: 30,
+			TimeoutSeconds:      100,
+		}
+		return nil
+	}
+	type probe Probe
+	return unmarshal((*probe)(p))
+}
+
+//Actions counts the check actions of the probe, a valid probe has exactly one
+func (p *Probe) Actions() int {
+	count := 0
+	for _, set := range []bool{p.HttpGet != nil, p.TcpSocket != nil, p.Exec != nil, p.Grpc != nil} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
@@ -2,17 +2,23 @@ package model
 
 type Resource struct {
 	ApiVersion string            `yaml:"apiVersion"`
-	Kind       string            `yaml:"kind"`
+	Kind       string            `yaml:"kind" enum:"Resource"`
 	Metadata   map[string]string `yaml:"metadata"`
 	Spec       ResourceSpec      `yaml:"spec"`
 }
 
+//ResourceSpec with configMap set renders the plain elements and attributes of the resource into
+//the ConfigMap of the app instead of individual env vars
 type ResourceSpec struct {
+	ConfigMap        bool               `yaml:"configMap"`
 	ResourceTemplate []ResourceTemplate `yaml:"template"`
 }
 
+//ResourceTemplate holds the elements of a resource for one environment,
+//elements named in secrets are rendered into a Secret instead of plain env vars
 type ResourceTemplate struct {
 	Name    string            `yaml:"name"`
 	Element map[string]string `yaml:"element"`
 	Infra   string            `yaml:"infrastructure"`
+	Secrets []string          `yaml:"secrets"`
 }
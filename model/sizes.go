@@ -0,0 +1,15 @@
+package model
+
+//SizeCatalogue maps the cpu and memory size classes used by mixins and app templates to kubernetes quantities
+type SizeCatalogue struct {
+	Cpu          map[string]string `yaml:"cpu"`
+	Memory       map[string]string `yaml:"memory"`
+	Environments []SizeOverride    `yaml:"environments"`
+}
+
+//SizeOverride replaces the quantity of size classes for one environment
+type SizeOverride struct {
+	Name   string            `yaml:"name"`
+	Cpu    map[string]string `yaml:"cpu"`
+	Memory map[string]string `yaml:"memory"`
+}
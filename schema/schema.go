@@ -0,0 +1,146 @@
+//go:generate go run ../cmd/template-gen schema --output-dir json
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const draft = "http://json-schema.org/draft-07/schema#"
+
+//KindRelease is the kind of a release spec, the other kinds are the manifest kinds of the model package
+const KindRelease = "Release"
+
+//Schema is the subset of JSON Schema the manifest types are described with
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+//types maps every schema kind to the go type its manifests are decoded into
+var types = map[string]interface{}{
+	model.KindApplication:    model.Application{},
+	model.KindCapability:     model.Capability{},
+	model.KindEnvironments:   model.EnvironmentRegistry{},
+	model.KindInfrastructure: model.Infrastructure{},
+	model.KindMixin:          model.MixinList{},
+	model.KindPlacement:      model.PlacementPolicies{},
+	model.KindResource:       model.Resource{},
+	model.KindSizes:          model.SizeCatalogue{},
+	KindRelease:              model.AppSpec{},
+}
+
+//Kinds returns the kinds a schema is published for, sorted
+func Kinds() []string {
+	kinds := make([]string, 0, len(types))
+	for kind := range types {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+//For generates the schema of a manifest kind from its go type, the enum tag of a field lists its allowed values.
+//The kind is matched ignoring case
+func For(kind string) (*Schema, error) {
+	for _, k := range Kinds() {
+		if strings.EqualFold(k, kind) {
+			kind = k
+		}
+	}
+	t, ok := types[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %s, eg: %s", kind, strings.Join(Kinds(), ", "))
+	}
+	schema := generate(reflect.TypeOf(t))
+	schema.Schema = draft
+	schema.Title = kind
+	return schema, nil
+}
+
+//FileName is the name the schema of kind is published under, eg: application.schema.json
+func FileName(kind string) string {
+	return fmt.Sprintf("%s.schema.json", strings.ToLower(kind))
+}
+
+//Marshal renders the schema as indented json
+func (s *Schema) Marshal() ([]byte, error) {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func generate(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		schema := &Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*Schema, 0),
+			AdditionalProperties: false,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := fieldName(field)
+			if name == "" {
+				continue
+			}
+			property := generate(field.Type)
+			//a field with a custom decoder also accepts a scalar shorthand, eg: liveness_probe: /health
+			if field.Tag.Get("scalar") != "" {
+				property.Type = []string{"object", "string"}
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				property.Type = "string"
+				property.Enum = strings.Split(enum, ",")
+			}
+			schema.Properties[name] = property
+		}
+		return schema
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generate(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generate(t.Elem())}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Interface:
+		//any value, eg: the kubernetes affinity passed through as is
+		return &Schema{}
+	}
+	//yaml decodes any scalar into a string field, eg: replicas: 3
+	return &Schema{Type: []string{"string", "number", "boolean"}}
+}
+
+//fieldName is the yaml name of a field, empty for unexported and skipped fields
+func fieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/ioutil"
+	"testing"
+)
+
+func TestPublishedSchemasAreUpToDate(t *testing.T) {
+	for _, kind := range Kinds() {
+		s, err := For(kind)
+		test.Null(t, err)
+		content, err := s.Marshal()
+		test.Null(t, err)
+		published, err := ioutil.ReadFile("json/" + FileName(kind))
+		test.Null(t, err)
+		//regenerate with go generate ./schema
+		test.EqualTo(t, string(content), string(published))
+	}
+}
+
+func TestSchemaEnums(t *testing.T) {
+	s, err := For("application")
+	test.Null(t, err)
+	test.EqualTo(t, "Application", s.Title)
+	test.EqualTo(t, 4, len(s.Properties["kind"].Enum))
+	test.EqualTo(t, 3, len(s.Properties["restartPolicy"].Enum))
+
+	s, err = For(model.KindMixin)
+	test.Null(t, err)
+	test.EqualTo(t, "half", s.Properties["mixin"].Items.Properties["resource-limit-strategy"].Enum[0])
+
+	_, err = For("Secret")
+	test.NotNull(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	content := "name: api\nkind: Jobs\nservice:\n  port: http\ntemplate:\n  - name: test\n    confg: {}\n"
+	diagnostics, err := Validate(model.KindApplication, "api.yaml", []byte(content))
+	test.Null(t, err)
+	test.EqualTo(t, 3, len(diagnostics))
+	test.EqualTo(t, 2, diagnostics[0].Line)
+	test.EqualTo(t, "kind: Jobs is not one of Deployment, Job, CronJob, StatefulSet", diagnostics[0].Message)
+	test.EqualTo(t, "service.port: expected integer, found string", diagnostics[1].Message)
+	test.EqualTo(t, "template[0].confg: unknown field", diagnostics[2].Message)
+	test.EqualTo(t, 7, diagnostics[2].Line)
+
+	content = "name: api\ntemplate:\n  - name: test\n    config: {}\n  - name: prod\n    confg: {}\n---\nname: web\nconfg: {}\n"
+	diagnostics, err = Validate(model.KindApplication, "api.yaml", []byte(content))
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(diagnostics))
+	test.EqualTo(t, 6, diagnostics[0].Line)
+	test.EqualTo(t, 9, diagnostics[1].Line)
+
+	diagnostics, err = Validate(model.KindApplication, "api.yaml", []byte("name: api\ntemplate:\n  - name: test\n    config:\n      replicas: 3\n"))
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(diagnostics))
+
+	diagnostics, err = Validate(model.KindApplication, "api.yaml", []byte("name: api\nplacement:\n  affinity:\n    nodeAffinity: {}\n"))
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(diagnostics))
+}
+
+func TestKindOf(t *testing.T) {
+	test.EqualTo(t, model.KindResource, KindOf("provider/resources/cassandra.yaml"))
+	test.EqualTo(t, model.KindMixin, KindOf("mixins/java.yaml"))
+	test.EqualTo(t, model.KindSizes, KindOf("provider/sizes.yaml"))
+	test.EqualTo(t, model.KindApplication, KindOf("apps/busybox.yaml"))
+}
@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"gopkg.in/yaml.v2"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//KindOf guesses the kind of a manifest from its path in a provider or app directory,
+//eg: resources/cassandra.yaml is a Resource, any other file an Application
+func KindOf(file string) string {
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+	dir := path.Base(path.Dir(file))
+	switch {
+	case dir == "resources":
+		return model.KindResource
+	case dir == "infrastructure":
+		return model.KindInfrastructure
+	case dir == "mixins":
+		return model.KindMixin
+	case dir == "capabilities":
+		return model.KindCapability
+	case path.Base(file) == "sizes.yaml":
+		return model.KindSizes
+	case path.Base(file) == "environments.yaml":
+		return model.KindEnvironments
+	case path.Base(file) == "placement.yaml":
+		return model.KindPlacement
+	}
+	return model.KindApplication
+}
+
+//Validate checks the yaml content of a manifest against the schema of kind,
+//every violation is reported as an error diagnostic located at the offending key
+func Validate(kind string, file string, content []byte) (model.Diagnostics, error) {
+	schema, err := For(kind)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{
+		manifest:    &model.Manifest{Kind: kind, File: file, Content: content},
+		diagnostics: make(model.Diagnostics, 0),
+	}
+	//a multi document file, eg: a release spec, is validated document by document
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			v.diagnostics = append(v.diagnostics, model.NewDiagnostic(model.SeverityError, model.NewParseError(kind, file, err)))
+			break
+		}
+		v.validate(schema, document, "", nil)
+		v.document++
+	}
+	return v.diagnostics, nil
+}
+
+type validator struct {
+	manifest    *model.Manifest
+	document    int
+	diagnostics model.Diagnostics
+}
+
+//validate checks value against schema, segments are the keys and indexes of path to locate a violation
+func (v *validator) validate(schema *Schema, value interface{}, path string, segments []string) {
+	if value == nil {
+		return
+	}
+	if !matchesType(schema.Type, value) {
+		v.report(path, segments, "expected %s, found %s", typeNames(schema.Type), typeOf(value))
+		return
+	}
+	if len(schema.Enum) > 0 && !contains(schema.Enum, fmt.Sprint(value)) {
+		v.report(path, segments, "%v is not one of %s", value, strings.Join(schema.Enum, ", "))
+		return
+	}
+	if schema.Type == nil {
+		//an untyped schema accepts any value, eg: the affinity of a placement
+		return
+	}
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinPath(path, k)
+			childSegments := append(append(make([]string, 0, len(segments)+1), segments...), k)
+			property, ok := schema.Properties[k]
+			if !ok {
+				additional, isSchema := schema.AdditionalProperties.(*Schema)
+				if !isSchema {
+					v.report(child, childSegments, "unknown field")
+					continue
+				}
+				property = additional
+			}
+			v.validate(property, lookup(typed, k), child, childSegments)
+		}
+	case []interface{}:
+		if schema.Items == nil {
+			return
+		}
+		for i, item := range typed {
+			itemSegments := append(append(make([]string, 0, len(segments)+1), segments...), strconv.Itoa(i))
+			v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), itemSegments)
+		}
+	}
+}
+
+func (v *validator) report(path string, segments []string, format string, args ...interface{}) {
+	location := v.manifest.LocatePath("", v.document, segments...)
+	v.diagnostics = append(v.diagnostics, model.Diagnostic{
+		Severity: model.SeverityError,
+		Location: location,
+		Message:  fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)),
+	})
+}
+
+func lookup(items map[interface{}]interface{}, key string) interface{} {
+	for k, v := range items {
+		if fmt.Sprint(k) == key {
+			return v
+		}
+	}
+	return nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return typeMatches(t, value)
+	case []string:
+		for _, name := range t {
+			if typeMatches(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeMatches(name string, value interface{}) bool {
+	actual := typeOf(value)
+	return actual == name || (name == "number" && actual == "integer")
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	}
+	return "string"
+}
+
+func typeNames(schemaType interface{}) string {
+	if names, ok := schemaType.([]string); ok {
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(schemaType)
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
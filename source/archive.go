@@ -0,0 +1,80 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+//Tar reads every file of a tar archive, optionally gzip compressed, into an in-memory source
+func Tar(r io.Reader) (ManifestSource, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	files := Map{}
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = string(data)
+	}
+	return files, nil
+}
+
+//Zip reads manifests from a zip archive
+func Zip(r io.ReaderAt, size int64) (ManifestSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return FS(zr), nil
+}
+
+//Open returns the source of a local path, a .zip, .tar, .tar.gz or .tgz archive is read into memory,
+//any other path is used as a directory
+func Open(name string) (ManifestSource, error) {
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return Zip(bytes.NewReader(content), int64(len(content)))
+	case strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return Tar(file)
+	}
+	return Dir(name), nil
+}
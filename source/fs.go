@@ -0,0 +1,41 @@
+package source
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+)
+
+type fsSource struct {
+	fsys fs.FS
+}
+
+//FS reads manifests from a fs.FS, eg: an embed.FS bundled into the binary
+func FS(fsys fs.FS) ManifestSource {
+	return &fsSource{fsys: fsys}
+}
+
+//Dir reads manifests from a local directory
+func Dir(dir string) ManifestSource {
+	return FS(os.DirFS(dir))
+}
+
+func (f *fsSource) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, path.Clean(name))
+}
+
+func (f *fsSource) List(dir string) ([]string, error) {
+	entries, err := fs.ReadDir(f.fsys, path.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
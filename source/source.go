@@ -0,0 +1,90 @@
+package source
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//ManifestSource reads the app and provider manifests by slash separated path relative to its root,
+//eg: resources/cassandra.yaml. A missing manifest is reported with an error matching fs.ErrNotExist
+type ManifestSource interface {
+	ReadFile(name string) ([]byte, error)
+	//List returns the sorted names of the files directly under dir, "." lists the root
+	List(dir string) ([]string, error)
+}
+
+//Map is an in-memory source keyed by path, mostly useful for tests
+type Map map[string]string
+
+func (m Map) ReadFile(name string) ([]byte, error) {
+	content, ok := m[path.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return []byte(content), nil
+}
+
+func (m Map) List(dir string) ([]string, error) {
+	return listNames(m, dir), nil
+}
+
+type subSource struct {
+	src ManifestSource
+	dir string
+}
+
+//Sub returns the source rooted at dir of src, eg: Sub(bundle, "provider")
+func Sub(src ManifestSource, dir string) ManifestSource {
+	return &subSource{src: src, dir: path.Clean(dir)}
+}
+
+func (s *subSource) ReadFile(name string) ([]byte, error) {
+	return s.src.ReadFile(path.Join(s.dir, name))
+}
+
+func (s *subSource) List(dir string) ([]string, error) {
+	return s.src.List(path.Join(s.dir, dir))
+}
+
+func (s *subSource) Lenient() bool {
+	return IsLenient(s.src)
+}
+
+type lenientSource struct {
+	ManifestSource
+}
+
+//Lenient returns src decoded without strict checks, unknown fields are ignored and a kind differing only
+//in case is corrected. Use it for repos whose manifests predate strict decoding
+func Lenient(src ManifestSource) ManifestSource {
+	return &lenientSource{ManifestSource: src}
+}
+
+func (l *lenientSource) Lenient() bool {
+	return true
+}
+
+//IsLenient reports whether the manifests of src are decoded without strict checks, see Lenient
+func IsLenient(src ManifestSource) bool {
+	lenient, ok := src.(interface{ Lenient() bool })
+	return ok && lenient.Lenient()
+}
+
+func listNames(files map[string]string, dir string) []string {
+	dir = path.Clean(dir)
+	names := make([]string, 0)
+	for name := range files {
+		parent, file := path.Split(name)
+		parent = strings.TrimSuffix(parent, "/")
+		if parent == "" {
+			parent = "."
+		}
+		if parent == dir {
+			names = append(names, file)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
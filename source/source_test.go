@@ -0,0 +1,102 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+var manifests = map[string]string{
+	"apps/busybox.yaml":          "name: busybox\n",
+	"apps/nginx.yaml":            "name: nginx\n",
+	"provider/sizes.yaml":        "cpu: {}\n",
+	"provider/mixins/java.yaml":  "mixin: []\n",
+	"provider/resources/db.yaml": "kind: Resource\n",
+}
+
+func assertSource(t *testing.T, src ManifestSource) {
+	content, err := src.ReadFile("apps/busybox.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, "name: busybox\n", string(content))
+
+	names, err := src.List("apps")
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(names))
+	test.EqualTo(t, "nginx.yaml", names[1])
+
+	provider := Sub(src, "provider")
+	content, err = provider.ReadFile("mixins/java.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, "mixin: []\n", string(content))
+	names, err = provider.List(".")
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(names))
+
+	_, err = src.ReadFile("apps/missing.yaml")
+	test.EqualTo(t, true, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestMap(t *testing.T) {
+	assertSource(t, Map(manifests))
+}
+
+func TestLenient(t *testing.T) {
+	src := Lenient(Map(manifests))
+	assertSource(t, src)
+	test.EqualTo(t, true, IsLenient(src))
+	test.EqualTo(t, true, IsLenient(Sub(src, "provider")))
+	test.EqualTo(t, false, IsLenient(Sub(Map(manifests), "provider")))
+}
+
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range manifests {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	assertSource(t, FS(fsys))
+}
+
+func TestDir(t *testing.T) {
+	src := Dir("../sample-manifest")
+	names, err := src.List("user/apps")
+	test.Null(t, err)
+	test.EqualTo(t, "busybox.yaml", names[0])
+	_, err = src.ReadFile("provider/resources/missing.yaml")
+	test.EqualTo(t, true, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestTar(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range manifests {
+		tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+
+	src, err := Tar(buf)
+	test.Null(t, err)
+	assertSource(t, src)
+}
+
+func TestZip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range manifests {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	src, err := Zip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	test.Null(t, err)
+	assertSource(t, src)
+}
@@ -1,12 +1,15 @@
 package task
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"github.com/kube-sailmaker/template-gen/functions"
 	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
 	templates "github.com/kube-sailmaker/template-gen/template"
-	"log"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -15,9 +18,31 @@ const (
 	memory   = "memory"
 	replicas = "replicas"
 	sep      = "/"
+
+	resourceStrategy = "resource-limit-strategy"
+	command          = "cmd"
+	entrypoint       = "entrypoint"
+	envPrefix        = "env."
+	templateSource   = "template/"
 )
 
-//CPU value mapping
+//Resource limit strategy, requests are derived from the limits
+const (
+	strategyHalf  = "half"
+	strategyExact = "exact"
+	strategyNone  = "none"
+)
+
+//Sizing keys of the app template config, every other key is app configuration
+var reservedConfig = []string{cpu, memory, replicas}
+
+//Characters allowed in the key of app configuration, others can not be part of an env var name
+var configKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+//Workload kinds of an app manifest, an empty kind is a Deployment
+var workloadKinds = []string{"Deployment", "Job", "CronJob", "StatefulSet"}
+
+//CPU value mapping, the built-in size classes when the provider has no sizes.yaml
 var CPU = map[string]string{
 	"c05":     "0.5",
 	"c1":      "1",
@@ -26,7 +51,7 @@ var CPU = map[string]string{
 	"default": "0.5",
 }
 
-//Memory value mapping
+//Memory value mapping, the built-in size classes when the provider has no sizes.yaml
 var MEMORY = map[string]string{
 	"m05":     "0.5Gi",
 	"m1":      "1Gi",
@@ -35,183 +60,418 @@ var MEMORY = map[string]string{
 	"default": "256Mi",
 }
 
+//ProcessApplication resolves the template values of an app reading the manifests from the directories at appDir
+//and resourceDir, see ProcessApplicationFrom
 func ProcessApplication(app *model.App, releaseName string, namespace string, env string, appDir string, resourceDir string) (*templates.Application, error) {
+	return ProcessApplicationFrom(app, releaseName, namespace, env, source.Dir(appDir), source.Dir(resourceDir))
+}
+
+//ProcessApplicationFrom resolves the template values of an app. Every problem found is reported, the error is
+//the problem itself or, when there are several or the app has warnings, the model.Diagnostics listing them
+func ProcessApplicationFrom(app *model.App, releaseName string, namespace string, env string, apps source.ManifestSource, provider source.ManifestSource) (*templates.Application, error) {
 	if app == nil {
 		return nil, errors.New("app specification cannot be nil")
 	}
-	appFile := fmt.Sprintf("%s/%s.yaml", appDir, app.Name)
-	application := &model.Application{}
-	err := functions.UnmarshalFile(appFile, application)
+	application, manifest, err := LoadApplicationManifest(app.Name, apps)
 	if err != nil {
-		return nil, err
+		return nil, locateError(err, app.Name, nil)
+	}
+	appValues, errs := processApplication(app, application, releaseName, namespace, env, source.IsLenient(apps), provider)
+	if len(errs) == 1 && len(appValues.Warnings) == 0 {
+		return nil, locateError(errs[0], app.Name, manifest)
 	}
+	if len(errs) > 0 {
+		diagnostics := make(model.Diagnostics, 0, len(errs)+len(appValues.Warnings))
+		for _, err := range errs {
+			diagnostics = append(diagnostics, model.NewDiagnostic(model.SeverityError, locateError(err, app.Name, manifest)))
+		}
+		return nil, append(diagnostics, appValues.Warnings...)
+	}
+	return appValues, nil
+}
 
-	if application.Service == nil {
-		application.Service = &model.ServiceSpec{
-			Port:    8080,
-			Enabled: true,
+//processApplication keeps resolving the app after an error to report every problem at once, it only stops
+//when the environment or mixins the other values depend on cannot be resolved. The values resolved so far are
+//returned with the errors to keep their warnings
+func processApplication(app *model.App, application *model.Application, releaseName string, namespace string, env string, lenient bool, provider source.ManifestSource) (*templates.Application, []error) {
+	appValues := templates.Application{
+		Name:        app.Name,
+		Tag:         app.Version,
+		Kind:        application.Kind,
+		Namespace:   namespace,
+		ReleaseName: releaseName,
+		Annotations: application.Annotations,
+	}
+	errs := make([]error, 0)
+	//collect keeps err unless the same problem, eg: an unknown environment of the app templates, was already found
+	collect := func(err error) bool {
+		if err == nil {
+			return true
+		}
+		for _, e := range errs {
+			if e.Error() == err.Error() {
+				return false
+			}
 		}
+		errs = append(errs, err)
+		return false
 	}
-	if application.Service.Enabled {
-		if application.Service.Port == 0 {
-			application.Service.Port = 8080
+
+	collect(resolveKind(application, lenient, &appValues))
+	collect(GenerateWorkload(application, &appValues))
+	collect(GenerateService(application, &appValues))
+	var environment *model.Environment
+	environments, err := LoadEnvironments(provider)
+	if err == nil && environments != nil {
+		if env != "" {
+			environment, err = environments.Resolve(env)
+			if err == nil {
+				env = environment.Name
+			}
 		}
-		if application.Service.Port < 0 || application.Service.Port > 65535 {
-			return nil, fmt.Errorf("invalid port %d specified for %s", application.Service.Port, application.Name)
+		if err == nil {
+			application, err = environments.Normalize(application)
 		}
 	}
+	if !collect(err) {
+		return &appValues, errs
+	}
+	if environments == nil {
+		warnDefaultTemplate(application, env, &appValues)
+	}
+	mixins, err := LoadMixinsFrom(application, provider, &appValues)
+	if !collect(err) {
+		return &appValues, errs
+	}
+	mixin, sources := MergeMixins(mixins)
+	appValues.Sources = sources
+	collect(GenerateImage(app, application, env, &appValues))
 
-	appValues := templates.Application{
-		Name:           app.Name,
-		Tag:            app.Version,
-		Kind:           application.Kind,
-		Namespace:      namespace,
-		ReleaseName:    releaseName,
-		Annotations:    application.Annotations,
-		LivenessProbe:  application.LivenessProbe,
-		ReadinessProbe: application.ReadinessProbe,
-		ServiceEnabled: application.Service.Enabled,
-		ContainerPort:  application.Service.Port,
-	}
-
-	err = GenerateResourceLimit(application, env, &appValues)
-	if err != nil {
-		return nil, err
+	sizes, err := LoadSizesFrom(provider, env)
+	if collect(err) {
+		collect(GenerateResourceLimit(application, &mixin, sizes, env, &appValues))
 	}
-	err = GenerateEnvVars(application, resourceDir, &appValues)
-	if err != nil {
-		return nil, err
+	collect(generateEnvVars(application, environments, environment, provider, &appValues))
+	collect(GenerateTemplateConfig(application, env, &appValues))
+	GenerateMixins(&mixin, &appValues)
+	collect(GenerateProbes(application, &mixin, &appValues))
+	collect(GeneratePlacement(application, provider, environments, env, &appValues))
+	collect(GenerateStorage(application, env, &appValues))
+	collect(GenerateCapabilitiesFrom(application, provider, &appValues))
+	if len(errs) > 0 {
+		return &appValues, errs
 	}
-	err = GenerateMixins(application, resourceDir, &appValues)
+	GenerateConfigHash(&appValues)
+
+	return &appValues, nil
+}
+
+//LoadApplication reads the manifest of an app from the app directory
+func LoadApplication(name string, appDir string) (*model.Application, error) {
+	return LoadApplicationFrom(name, source.Dir(appDir))
+}
+
+//LoadApplicationFrom reads the manifest of an app from the app source
+func LoadApplicationFrom(name string, apps source.ManifestSource) (*model.Application, error) {
+	application, _, err := LoadApplicationManifest(name, apps)
+	return application, err
+}
+
+//LoadApplicationManifest reads the manifest of an app and keeps its raw content to locate errors
+func LoadApplicationManifest(name string, apps source.ManifestSource) (*model.Application, *model.Manifest, error) {
+	application := &model.Application{}
+	manifest, err := functions.UnmarshalManifest(apps, model.KindApplication, fmt.Sprintf(appManifest, name), application)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return application, manifest, nil
+}
 
-	return &appValues, nil
+//locateError attaches the app name to a located error, and the line of the offending value when it
+//comes from the app manifest itself
+func locateError(err error, app string, manifest *model.Manifest) error {
+	var located model.LocatedError
+	if !errors.As(err, &located) {
+		return err
+	}
+	location := located.Locate()
+	if location.App == "" {
+		location.App = app
+	}
+	if manifest == nil || location.Kind != manifest.Kind || location.File != "" {
+		return err
+	}
+	token := ""
+	switch e := err.(type) {
+	case *model.InvalidValueError:
+		//prefer the value under its own key, eg: cpu: c9
+		*location = manifest.LocateField(location.App, e.Field, e.Value)
+		if location.Line > 0 {
+			return err
+		}
+		token = e.Value
+	case *model.InvalidReferenceError:
+		token = e.Reference
+	}
+	*location = manifest.Locate(location.App, token)
+	return err
+}
+
+//resolveKind checks the workload kind of the app. When the app is decoded leniently a kind differing only in case,
+//eg: job, is corrected and an unknown kind renders no workload, both with a warning
+func resolveKind(application *model.Application, lenient bool, appValues *templates.Application) error {
+	if application.Kind == "" {
+		return nil
+	}
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	for _, kind := range workloadKinds {
+		if application.Kind == kind {
+			return nil
+		}
+		if strings.EqualFold(application.Kind, kind) && lenient {
+			warn(appValues, location, "kind %s should be written %s", application.Kind, kind)
+			appValues.Kind = kind
+			return nil
+		}
+	}
+	reason := fmt.Sprintf("eg: %s", strings.Join(workloadKinds, ", "))
+	if suggestion := functions.Suggest(application.Kind, workloadKinds); suggestion != "" {
+		reason = fmt.Sprintf("did you mean %s?", suggestion)
+	}
+	if lenient {
+		warn(appValues, location, "unknown kind %s, %s", application.Kind, reason)
+		return nil
+	}
+	return &model.InvalidValueError{
+		Location: location,
+		Field:    "kind",
+		Value:    application.Kind,
+		Reason:   reason,
+	}
 }
 
-//Function to set the mixins
-func GenerateMixins(application *model.Application, resourceDir string, appValues *templates.Application) error {
-	appValues.Command = make([]string, 0)
-	appValues.Entrypoint = make([]string, 0)
-	for _, mxin := range application.Mixins {
-		mixinType := strings.Split(mxin, sep)
-		if len(mixinType) < 2 {
-			eMsg := fmt.Sprintf("application mixin %s has missing value, eg: java/java-default", mixinType)
-			return errors.New(eMsg)
-		}
-		name := mixinType[0]
-		mType := mixinType[1]
-		mixinList := model.MixinList{}
-		err := GetMixin(name, &mixinList, resourceDir)
+//Function to set resource limit, request and replicas.
+//Mixin values are the defaults, the config of the matching app template overrides them.
+//An empty environment resolves the environment agnostic values and skips the app templates,
+//see ResolveTemplate for the inheritance of the templates
+func GenerateResourceLimit(application *model.Application, mixin *model.Mixin, sizes *Sizes, environment string, appValues *templates.Application) error {
+	sizing := map[string]string{
+		cpu:      mixin.Cpu,
+		memory:   mixin.Memory,
+		replicas: mixin.Replicas,
+	}
+	if len(application.Template) == 0 {
+		warn(appValues, model.Location{App: application.Name, Kind: model.KindApplication}, "missing template, applying default values")
+	} else if environment != "" {
+		//Process app template
+		tmpl, err := ResolveTemplate(application, environment)
 		if err != nil {
 			return err
 		}
-		match := false
-		for _, m := range mixinList.Mixin {
-			if mType == m.Name {
-				for k, v := range m.Env {
-					appValues.EnvVars[k] = v
-				}
-				appValues.Command = m.Cmd
-				appValues.Entrypoint = m.Entrypoint
-				match = true
-				break
+		for _, key := range reservedConfig {
+			if val, ok := tmpl.Config[key]; ok && val != "" {
+				sizing[key] = val
+				setSource(appValues, key, val, templateSource+tmpl.Origin[key])
 			}
 		}
-		if match == false {
-			log.Print(fmt.Sprintf("[WARN] could not find matching mixin %s of app %s", mType, application.Name))
+	}
+
+	cpuSize, err := sizes.ResolveCpu(sizing[cpu])
+	if err != nil {
+		return sizingError(err, application, appValues, cpu, sizing[cpu])
+	}
+	memSize, err := sizes.ResolveMemory(sizing[memory])
+	if err != nil {
+		return sizingError(err, application, appValues, memory, sizing[memory])
+	}
+	appValues.Replicas = "1"
+	if sizing[replicas] != "" {
+		appValues.Replicas = sizing[replicas]
+	}
+
+	appValues.Limits = make(map[string]string, 0)
+	appValues.Requests = make(map[string]string, 0)
+	switch mixin.ResourceStrategy {
+	case "", strategyExact:
+		appValues.Limits[cpu] = cpuSize
+		appValues.Limits[memory] = memSize
+		appValues.Requests[cpu] = cpuSize
+		appValues.Requests[memory] = memSize
+	case strategyHalf:
+		cpuRequest, err := halfQuantity(cpuSize)
+		if err != nil {
+			return sizingError(err, application, appValues, cpu, sizing[cpu])
+		}
+		memRequest, err := halfQuantity(memSize)
+		if err != nil {
+			return sizingError(err, application, appValues, memory, sizing[memory])
+		}
+		appValues.Limits[cpu] = cpuSize
+		appValues.Limits[memory] = memSize
+		appValues.Requests[cpu] = cpuRequest
+		appValues.Requests[memory] = memRequest
+	case strategyNone:
+		appValues.Requests[cpu] = cpuSize
+		appValues.Requests[memory] = memSize
+	default:
+		return &model.InvalidValueError{
+			Location: model.Location{App: application.Name, Kind: model.KindMixin},
+			Field:    resourceStrategy,
+			Value:    mixin.ResourceStrategy,
+			Reason:   "eg: half, exact, none",
 		}
 	}
 	return nil
 }
 
-//Function to set resource limit, request and replicas
-func GenerateResourceLimit(application *model.Application, environment string, appValues *templates.Application) error {
-	appValues.Limits = make(map[string]string, 0)
-	//apply default values if missing
-	if len(application.Template) == 0 {
-		appValues.Limits[cpu] = CPU["default"]
-		appValues.Limits[memory] = MEMORY["default"]
-		appValues.Replicas = "1"
-		log.Println("[WARN] missing resource, applying default values for application", application.Name)
-		return nil
+//sizingError locates an invalid cpu or memory value, in the app manifest when a template sets it,
+//otherwise in the mixins supplying the default
+func sizingError(err error, application *model.Application, appValues *templates.Application, field string, value string) error {
+	var invalid *model.InvalidValueError
+	if !errors.As(err, &invalid) {
+		invalid = &model.InvalidValueError{Field: field, Value: value, Reason: err.Error()}
 	}
-	//Process app template
-	found := false
-	for _, tmpl := range application.Template {
-		if tmpl.Name == environment {
-			found = true
-			configs := tmpl.Config
-			cpuLimit := CPU["default"]
-			if val, ok := configs[cpu]; ok && val != "" {
-				cpuLimit = CPU[val]
-			}
-			memLimit := MEMORY["default"]
-			if val, ok := configs[memory]; ok && val != "" {
-				memLimit = MEMORY[val]
-			}
-
-			replicaLimit := "1"
-			if val, ok := configs[replicas]; ok && val != "" {
-				replicaLimit = val
-			}
-			appValues.Limits[cpu] = cpuLimit
-			appValues.Limits[memory] = memLimit
-			appValues.Replicas = replicaLimit
-			break
+	invalid.App = application.Name
+	invalid.Kind = model.KindMixin
+	for _, s := range appValues.Sources {
+		if s.Field == field && strings.HasPrefix(s.Source, templateSource) {
+			invalid.Kind = model.KindApplication
 		}
 	}
-	if !found {
-		return fmt.Errorf("unknown environment %s", environment)
-	}
-	return nil
+	return invalid
 }
 
-//Function to set environment variable from resources
+//GenerateEnvVars sets the environment variables of the resources of the provider directory, see GenerateEnvVarsFrom
 func GenerateEnvVars(application *model.Application, resourceDir string, appValues *templates.Application) error {
+	return GenerateEnvVarsFrom(application, source.Dir(resourceDir), appValues)
+}
+
+//Function to set environment variable from resources
+//Elements and attributes marked as secrets are collected in SecretEnvVars instead, to be referenced from a Secret,
+//the plain ones of a resource or app with configMap set in ConfigEnvVars, to be loaded from a ConfigMap
+func GenerateEnvVarsFrom(application *model.Application, provider source.ManifestSource, appValues *templates.Application) error {
+	return generateEnvVars(application, nil, nil, provider, appValues)
+}
+
+//generateEnvVars resolves a resource reference without template type, eg: postgres, to the resource template of
+//the environment and an infrastructure reference without template type to the infrastructure template of the
+//environment. References without template type are skipped without an environment, eg: by the environment agnostic
+//values, explicit template types must be used by an environment of the registry
+func generateEnvVars(application *model.Application, environments *Environments, environment *model.Environment, provider source.ManifestSource, appValues *templates.Application) error {
 	appEnvVars := make(map[string]string, 0)
+	secretEnvVars := make(map[string]string, 0)
+	configEnvVars := make(map[string]string, 0)
+	//set before any error, the processing of the app goes on to report its other problems
+	appValues.EnvVars = appEnvVars
+	appValues.SecretEnvVars = secretEnvVars
+	appValues.ConfigEnvVars = configEnvVars
 
 	for _, appRes := range application.Resources {
 		//elasticsearch-user:sit
 		resDetails := strings.Split(appRes, sep)
+		location := model.Location{App: application.Name, Kind: model.KindApplication}
 		if len(resDetails) < 2 {
-			eMsg := fmt.Sprintf("application resource %s has missing template type, eg: cassandra/test1", resDetails)
-			return errors.New(eMsg)
+			if environments == nil {
+				return &model.InvalidReferenceError{
+					Location:  location,
+					Reference: appRes,
+					Reason:    "missing template type, eg: cassandra/test1",
+				}
+			}
+			if environment == nil {
+				continue
+			}
+			resDetails = append(resDetails, environment.Resource)
+		} else if environments != nil && !environments.hasResource(resDetails[1]) {
+			return &model.InvalidReferenceError{
+				Location:  location,
+				Reference: appRes,
+				Reason:    fmt.Sprintf("template type %s is not the resource template of an environment", resDetails[1]),
+			}
 		}
 		name := resDetails[0]
 		envType := resDetails[1]
 		resource := &model.Resource{}
-		err := GetResource(name, &resource, resourceDir)
+		err := GetResourceFrom(name, &resource, provider)
 		if err != nil {
 			return err
 		}
+		if environments != nil {
+			location := model.Location{App: application.Name, Kind: model.KindResource, File: fmt.Sprintf(resourceManifest, name)}
+			for _, resTemplate := range resource.Spec.ResourceTemplate {
+				if !environments.hasResource(resTemplate.Name) {
+					warn(appValues, location, "template %s of resource %s is not the resource template of an environment", resTemplate.Name, name)
+				}
+			}
+		}
+		plainEnvVars := appEnvVars
+		if application.ConfigMap || resource.Spec.ConfigMap {
+			plainEnvVars = configEnvVars
+		}
 		matchEnvType := false
 		for _, resTemplate := range resource.Spec.ResourceTemplate {
 			//Only using the context
 			if resTemplate.Name == envType {
-				addToEnvVars(name, appEnvVars, resTemplate.Element)
+				location := model.Location{App: application.Name, Kind: model.KindResource, File: fmt.Sprintf(resourceManifest, name)}
+				plain, secret, err := splitSecrets(resTemplate.Element, resTemplate.Secrets, location)
+				if err != nil {
+					return err
+				}
+				addToEnvVars(name, plainEnvVars, plain)
+				addToEnvVars(name, secretEnvVars, secret)
 
 				if len(resTemplate.Infra) > 0 {
 					infra := strings.Split(resTemplate.Infra, sep)
+					location := model.Location{App: application.Name, Kind: model.KindResource, File: fmt.Sprintf(resourceManifest, name)}
 					if len(infra) < 2 {
-						eMsg := fmt.Sprintf("resource infrastructure %s has missing template type, eg: cassandra-a/test", infra)
-						return errors.New(eMsg)
+						if environment == nil {
+							return &model.InvalidReferenceError{
+								Location:  location,
+								Reference: resTemplate.Infra,
+								Reason:    "missing template type, eg: cassandra-a/test",
+							}
+						}
+						infra = append(infra, environment.Infra)
+					} else if environments != nil && !environments.hasInfra(infra[1]) {
+						return &model.InvalidReferenceError{
+							Location:  location,
+							Reference: resTemplate.Infra,
+							Reason:    fmt.Sprintf("template type %s is not the infrastructure template of an environment", infra[1]),
+						}
 					}
 					infraName := infra[0]
 					infraEnv := infra[1]
 					infrastructure := &model.Infrastructure{}
-					GetInfrastructure(infraName, &infrastructure, resourceDir)
+					err := GetInfrastructureFrom(infraName, &infrastructure, provider)
+					if err != nil {
+						diagnostic := model.NewDiagnostic(model.SeverityWarning, err)
+						diagnostic.App = application.Name
+						appValues.Warnings = append(appValues.Warnings, diagnostic)
+					} else if environments != nil {
+						location := model.Location{App: application.Name, Kind: model.KindInfrastructure, File: fmt.Sprintf(infraManifest, infraName)}
+						for _, infraTemplate := range infrastructure.Spec.Template {
+							if !environments.hasInfra(infraTemplate.Name) {
+								warn(appValues, location, "template %s of infrastructure %s is not the infrastructure template of an environment", infraTemplate.Name, infraName)
+							}
+						}
+					}
 					matchInfra := false
 					for _, infraTemplate := range infrastructure.Spec.Template {
 						if infraEnv == infraTemplate.Name {
-							addToEnvVars(name, appEnvVars, infraTemplate.Attributes)
+							location := model.Location{App: application.Name, Kind: model.KindInfrastructure, File: fmt.Sprintf(infraManifest, infraName)}
+							plain, secret, err := splitSecrets(infraTemplate.Attributes, infraTemplate.Secrets, location)
+							if err != nil {
+								return err
+							}
+							addToEnvVars(name, plainEnvVars, plain)
+							addToEnvVars(name, secretEnvVars, secret)
 							matchInfra = true
 							break
 						}
 					}
-					if matchInfra == false {
-						log.Print(fmt.Sprintf("[WARN] could not find matching infra for env type %s of %s", infraEnv, resTemplate.Infra))
+					if err == nil && matchInfra == false {
+						location := model.Location{App: application.Name, Kind: model.KindInfrastructure, File: fmt.Sprintf(infraManifest, infraName)}
+						warn(appValues, location, "could not find matching infra for env type %s of %s", infraEnv, resTemplate.Infra)
 					}
 				}
 				matchEnvType = true
@@ -219,13 +479,136 @@ func GenerateEnvVars(application *model.Application, resourceDir string, appValu
 			}
 		}
 		if matchEnvType == false {
-			log.Print(fmt.Sprintf("[WARN] could not find matching env type %s of app %s", envType, application.Name))
+			location := model.Location{App: application.Name, Kind: model.KindResource, File: fmt.Sprintf(resourceManifest, name)}
+			warn(appValues, location, "could not find matching env type %s of resource %s", envType, name)
 		}
 	}
-	appValues.EnvVars = appEnvVars
+	for k := range secretEnvVars {
+		delete(appEnvVars, k)
+		delete(configEnvVars, k)
+	}
 	return nil
 }
 
+//GenerateTemplateConfig passes the config of the matching app template, except the reserved sizing keys, to the app.
+//A key is named as an env var of the upper cased key prefixed with configPrefix, dots and dashes replaced by underscores,
+//it lands in the ConfigMap when configMap is set and overrides a resource env var of the same name
+func GenerateTemplateConfig(application *model.Application, environment string, appValues *templates.Application) error {
+	if environment == "" {
+		return nil
+	}
+	target := appValues.EnvVars
+	if application.ConfigMap {
+		target = appValues.ConfigEnvVars
+	}
+	if len(application.Template) == 0 {
+		return nil
+	}
+	tmpl, err := ResolveTemplate(application, environment)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(tmpl.Config))
+	for k := range tmpl.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if isReservedConfig(key) {
+			continue
+		}
+		location := model.Location{App: application.Name, Kind: model.KindApplication}
+		if !configKeyPattern.MatchString(key) {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "config key",
+				Value:    key,
+				Reason:   "eg: logging_level, logging.level",
+			}
+		}
+		name := ConfigEnvName(application.ConfigPrefix, key)
+		if _, ok := appValues.SecretEnvVars[name]; ok {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "config key",
+				Value:    key,
+				Reason:   fmt.Sprintf("%s is a secret of a resource", name),
+			}
+		}
+		_, inEnv := appValues.EnvVars[name]
+		_, inConfig := appValues.ConfigEnvVars[name]
+		if inEnv || inConfig {
+			warn(appValues, location, "config %s of template %s overrides resource env var %s", key, tmpl.Origin[key], name)
+		}
+		target[name] = tmpl.Config[key]
+		setSource(appValues, name, tmpl.Config[key], templateSource+tmpl.Origin[key])
+	}
+	return nil
+}
+
+//ConfigEnvName is the env var name of an app template config key
+func ConfigEnvName(prefix string, key string) string {
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(prefix + key)
+	return strings.ToUpper(name)
+}
+
+func isReservedConfig(key string) bool {
+	for _, k := range reservedConfig {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+//GenerateConfigHash annotates the pod template with the hash of the ConfigMap content,
+//so a configuration change rolls the pods out
+func GenerateConfigHash(appValues *templates.Application) {
+	if len(appValues.ConfigEnvVars) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(appValues.ConfigEnvVars))
+	for k := range appValues.ConfigEnvVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", k, appValues.ConfigEnvVars[k])
+	}
+	if appValues.PodAnnotations == nil {
+		appValues.PodAnnotations = make(map[string]string, 0)
+	}
+	appValues.PodAnnotations[templates.ConfigHashAnnotation] = fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+//splitSecrets separates the items named in secrets from the plain items, naming an undefined item is an error
+func splitSecrets(items map[string]string, secrets []string, location model.Location) (map[string]string, map[string]string, error) {
+	plain := make(map[string]string, len(items))
+	for k, v := range items {
+		plain[k] = v
+	}
+	secret := make(map[string]string, len(secrets))
+	for _, k := range secrets {
+		v, ok := items[k]
+		if !ok {
+			return nil, nil, &model.InvalidReferenceError{
+				Location:  location,
+				Reference: k,
+				Reason:    "secret is not an element or attribute of the template",
+			}
+		}
+		secret[k] = v
+		delete(plain, k)
+	}
+	return plain, secret, nil
+}
+
+//warn records a problem that does not stop the generation, strict validation reports it as an error
+func warn(appValues *templates.Application, location model.Location, format string, args ...interface{}) {
+	appValues.Warnings = append(appValues.Warnings, model.Warning(location, format, args...))
+}
+
 func addToEnvVars(name string, appEnvVars map[string]string, items map[string]string) {
 	infraName := strings.ReplaceAll(name, "-", "_")
 	for k, v := range items {
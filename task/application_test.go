@@ -0,0 +1,213 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestGenerateResourceLimitUsesMixinDefaults(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Template: []model.AppTemplate{
+			{Name: "test", Config: map[string]string{"memory": "m2"}},
+		},
+	}
+	mixin := &model.Mixin{Cpu: "c1", Memory: "m1", Replicas: "3", ResourceStrategy: "half"}
+	appValues := &templates.Application{}
+	err := GenerateResourceLimit(application, mixin, DefaultSizes(), "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "3", appValues.Replicas)
+	test.EqualTo(t, "1", appValues.Limits["cpu"])
+	test.EqualTo(t, "2Gi", appValues.Limits["memory"])
+	test.EqualTo(t, "500m", appValues.Requests["cpu"])
+	test.EqualTo(t, "1024Mi", appValues.Requests["memory"])
+}
+
+func TestGenerateResourceLimitWithoutLimits(t *testing.T) {
+	application := &model.Application{Name: "api"}
+	mixin := &model.Mixin{Cpu: "c2", Memory: "m05", ResourceStrategy: "none"}
+	appValues := &templates.Application{}
+	err := GenerateResourceLimit(application, mixin, DefaultSizes(), "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(appValues.Limits))
+	test.EqualTo(t, "2", appValues.Requests["cpu"])
+	test.EqualTo(t, "0.5Gi", appValues.Requests["memory"])
+}
+
+func TestGenerateResourceLimitRejectsUnknownStrategy(t *testing.T) {
+	application := &model.Application{Name: "api"}
+	mixin := &model.Mixin{ResourceStrategy: "double"}
+	err := GenerateResourceLimit(application, mixin, DefaultSizes(), "test", &templates.Application{})
+	test.NotNull(t, err)
+}
+
+func TestHalfQuantity(t *testing.T) {
+	for quantity, expected := range map[string]string{
+		"0.5":   "250m",
+		"3":     "1500m",
+		"500m":  "250m",
+		"0.5Gi": "256Mi",
+		"256Mi": "128Mi",
+		"1M":    "500k",
+	} {
+		half, err := halfQuantity(quantity)
+		test.Null(t, err)
+		test.EqualTo(t, expected, half)
+	}
+	_, err := halfQuantity("")
+	test.NotNull(t, err)
+}
+
+func TestProcessApplicationLocatesErrors(t *testing.T) {
+	apps := source.Map{
+		"api.yaml": "name: api\ntemplate:\n  - name: test\n    config:\n      cpu: c9\n",
+	}
+	_, err := ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "test", apps, source.Map{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "api", invalid.App)
+	test.EqualTo(t, "api.yaml", invalid.File)
+	test.EqualTo(t, 5, invalid.Line)
+	test.EqualTo(t, 7, invalid.Column)
+
+	_, err = ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "prod", apps, source.Map{})
+	var unknown *model.UnknownEnvironmentError
+	test.EqualTo(t, true, errors.As(err, &unknown))
+	test.EqualTo(t, "prod", unknown.Environment)
+	test.EqualTo(t, "[app]: api, [file]: api.yaml, [error]: unknown environment prod", err.Error())
+
+	_, err = ProcessApplicationFrom(&model.App{Name: "web"}, "release", "apps", "test", apps, source.Map{})
+	var missing *model.ManifestNotFoundError
+	test.EqualTo(t, true, errors.As(err, &missing))
+	test.EqualTo(t, "web", missing.App)
+	test.EqualTo(t, "web.yaml", missing.File)
+}
+
+func TestProcessApplicationChecksKind(t *testing.T) {
+	apps := source.Map{
+		"batch.yaml": "name: batch\nkind: job\ntemplate:\n  - name: test\n",
+	}
+	_, err := ProcessApplicationFrom(&model.App{Name: "batch"}, "release", "apps", "test", apps, source.Map{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "did you mean Job?", invalid.Reason)
+	test.EqualTo(t, 2, invalid.Line)
+
+	appValues, err := ProcessApplicationFrom(&model.App{Name: "batch"}, "release", "apps", "test", source.Lenient(apps), source.Map{})
+	test.Null(t, err)
+	test.EqualTo(t, "Job", appValues.Kind)
+	test.EqualTo(t, 1, len(appValues.Warnings))
+}
+
+func TestProcessApplicationKeepsWarningsOfAFailingApp(t *testing.T) {
+	apps := source.Map{
+		"batch.yaml": "name: batch\nkind: job\ntemplate:\n  - name: test\n    config:\n      cpu: c9\n",
+	}
+	_, err := ProcessApplicationFrom(&model.App{Name: "batch"}, "release", "apps", "test", source.Lenient(apps), source.Map{})
+	var diagnostics model.Diagnostics
+	test.EqualTo(t, true, errors.As(err, &diagnostics))
+	test.EqualTo(t, 1, len(diagnostics.Errors()))
+	test.EqualTo(t, 1, len(diagnostics.Warnings()))
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "cpu", invalid.Field)
+}
+
+func TestProcessApplicationReportsMissingResourceWithTemplateConfig(t *testing.T) {
+	apps := source.Map{
+		"api.yaml": "name: api\nresources:\n  - db/test\ntemplate:\n  - name: test\n    config:\n      logging_level: DEBUG\n",
+	}
+	_, err := ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "test", apps, source.Map{})
+	var missing *model.ManifestNotFoundError
+	test.EqualTo(t, true, errors.As(err, &missing))
+	test.EqualTo(t, "resources/db.yaml", missing.File)
+}
+
+func TestProcessApplicationFromDirectories(t *testing.T) {
+	app := &model.App{Name: "busybox", Version: "1.0"}
+	appValues, err := ProcessApplication(app, "release", "apps", "test", "../sample-manifest/user/apps", "../sample-manifest/provider")
+	test.Null(t, err)
+	test.EqualTo(t, "busybox", appValues.Name)
+}
+
+func TestGenerateEnvVarsKeepsSecretsOutOfEnv(t *testing.T) {
+	application := &model.Application{
+		Name:      "eod-job",
+		Resources: []string{"postgres/test1"},
+	}
+	appValues := &templates.Application{}
+	err := GenerateEnvVars(application, "../sample-manifest/provider", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "tst_user", appValues.EnvVars["POSTGRES_DATABASE"])
+	test.EqualTo(t, "test-changeit", appValues.SecretEnvVars["POSTGRES_PASSWORD"])
+	_, ok := appValues.EnvVars["POSTGRES_PASSWORD"]
+	test.EqualTo(t, false, ok)
+
+	provider := source.Map{
+		"resources/db.yaml": "kind: Resource\nspec:\n  template:\n    - name: test\n      element:\n        user: app\n      secrets:\n        - password\n",
+	}
+	err = GenerateEnvVarsFrom(&model.Application{Name: "api", Resources: []string{"db/test"}}, provider, &templates.Application{})
+	var invalid *model.InvalidReferenceError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "password", invalid.Reference)
+	test.EqualTo(t, "resources/db.yaml", invalid.File)
+}
+
+func TestGenerateEnvVarsIntoConfigMap(t *testing.T) {
+	provider := source.Map{
+		"resources/db.yaml": "kind: Resource\nspec:\n  configMap: true\n  template:\n    - name: test\n      element:\n        database: tst_user\n",
+	}
+	application := &model.Application{
+		Name:      "eod-job",
+		Resources: []string{"db/test"},
+	}
+	appValues := &templates.Application{}
+	err := GenerateEnvVarsFrom(application, provider, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "tst_user", appValues.ConfigEnvVars["DB_DATABASE"])
+	test.EqualTo(t, 0, len(appValues.EnvVars))
+
+	GenerateConfigHash(appValues)
+	hash := appValues.PodAnnotations[templates.ConfigHashAnnotation]
+	test.EqualTo(t, 64, len(hash))
+	appValues.ConfigEnvVars["DB_DATABASE"] = "sit_user"
+	GenerateConfigHash(appValues)
+	test.EqualTo(t, false, hash == appValues.PodAnnotations[templates.ConfigHashAnnotation])
+}
+
+func TestGenerateTemplateConfig(t *testing.T) {
+	application := &model.Application{
+		Name:         "busybox",
+		ConfigPrefix: "app.",
+		Template: []model.AppTemplate{
+			{Name: "test", Config: map[string]string{"cpu": "c1", "logging-level": "DEBUG", "POSTGRES_HOST": "db"}},
+		},
+	}
+	appValues := &templates.Application{
+		EnvVars:       map[string]string{"APP_POSTGRES_HOST": "localhost"},
+		ConfigEnvVars: map[string]string{},
+	}
+	err := GenerateTemplateConfig(application, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "DEBUG", appValues.EnvVars["APP_LOGGING_LEVEL"])
+	test.EqualTo(t, "db", appValues.EnvVars["APP_POSTGRES_HOST"])
+	test.EqualTo(t, "", appValues.EnvVars["APP_CPU"])
+	test.EqualTo(t, 1, len(appValues.Warnings))
+
+	application.ConfigMap = true
+	appValues = &templates.Application{EnvVars: map[string]string{}, ConfigEnvVars: map[string]string{}}
+	err = GenerateTemplateConfig(application, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "DEBUG", appValues.ConfigEnvVars["APP_LOGGING_LEVEL"])
+	test.EqualTo(t, 0, len(appValues.EnvVars))
+
+	application.Template[0].Config["log level"] = "INFO"
+	err = GenerateTemplateConfig(application, "test", appValues)
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "log level", invalid.Value)
+}
@@ -0,0 +1,110 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"io/fs"
+)
+
+//GenerateCapabilities applies the capability bundles of the provider directory, see GenerateCapabilitiesFrom
+func GenerateCapabilities(application *model.Application, resourceDir string, appValues *templates.Application) error {
+	return GenerateCapabilitiesFrom(application, source.Dir(resourceDir), appValues)
+}
+
+//Function to apply the capability bundles requested by the application
+func GenerateCapabilitiesFrom(application *model.Application, provider source.ManifestSource, appValues *templates.Application) error {
+	appValues.PodAnnotations = make(map[string]string, 0)
+	appValues.Volumes = make([]templates.Volume, 0)
+	appValues.Sidecars = make([]templates.Sidecar, 0)
+	appValues.Rules = make([]templates.PolicyRule, 0)
+	if appValues.EnvVars == nil {
+		appValues.EnvVars = make(map[string]string, 0)
+	}
+
+	volumes := make(map[string]bool, 0)
+	for _, name := range application.Capabilities {
+		capability := &model.Capability{}
+		err := GetCapabilityFrom(name, capability, provider)
+		if errors.Is(err, fs.ErrNotExist) {
+			return &model.InvalidReferenceError{
+				Location:  model.Location{App: application.Name, Kind: model.KindApplication},
+				Reference: name,
+				Reason:    "unknown capability",
+				Err:       err,
+			}
+		}
+		if err != nil {
+			return err
+		}
+		spec := capability.Spec
+		for k, v := range spec.Annotations {
+			appValues.PodAnnotations[k] = v
+		}
+		for k, v := range spec.Env {
+			appValues.EnvVars[k] = v
+		}
+		for _, v := range spec.Volumes {
+			if volumes[v.Name] {
+				return &model.InvalidValueError{
+					Location: model.Location{App: application.Name, Kind: model.KindCapability, File: fmt.Sprintf(capabilityManifest, name)},
+					Field:    "volume",
+					Value:    v.Name,
+					Reason:   "already defined by another capability",
+				}
+			}
+			volumes[v.Name] = true
+			appValues.Volumes = append(appValues.Volumes, toVolume(v))
+		}
+		for _, s := range spec.Sidecars {
+			sidecar := templates.Sidecar{
+				Name:         s.Name,
+				Image:        s.Image,
+				Args:         s.Args,
+				Env:          s.Env,
+				Port:         s.Port,
+				VolumeMounts: make([]templates.Volume, 0),
+			}
+			for _, m := range s.VolumeMounts {
+				sidecar.VolumeMounts = append(sidecar.VolumeMounts, templates.Volume{
+					Name:      m.Name,
+					MountPath: m.MountPath,
+					ReadOnly:  m.ReadOnly,
+				})
+			}
+			appValues.Sidecars = append(appValues.Sidecars, sidecar)
+		}
+		for _, r := range spec.Rules {
+			appValues.Rules = append(appValues.Rules, templates.PolicyRule{
+				ApiGroups: r.ApiGroups,
+				Resources: r.Resources,
+				Verbs:     r.Verbs,
+			})
+		}
+	}
+
+	for _, s := range appValues.Sidecars {
+		for _, m := range s.VolumeMounts {
+			if !volumes[m.Name] {
+				return &model.InvalidReferenceError{
+					Location:  model.Location{App: application.Name, Kind: model.KindCapability},
+					Reference: m.Name,
+					Reason:    fmt.Sprintf("sidecar %s mounts an undefined volume", s.Name),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func toVolume(v model.Volume) templates.Volume {
+	return templates.Volume{
+		Name:      v.Name,
+		MountPath: v.MountPath,
+		ReadOnly:  v.ReadOnly,
+		Secret:    v.Secret,
+		ConfigMap: v.ConfigMap,
+	}
+}
@@ -0,0 +1,42 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/fs"
+	"testing"
+)
+
+func TestGenerateCapabilities(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	application := &model.Application{
+		Name:         "busybox",
+		Capabilities: []string{"prometheus", "vault", "read-kubernetes"},
+	}
+	appValues := &templates.Application{}
+	err := GenerateCapabilitiesFrom(application, provider, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "true", appValues.PodAnnotations["prometheus.io/scrape"])
+	test.EqualTo(t, "/vault/secrets", appValues.EnvVars["VAULT_SECRETS_PATH"])
+	test.EqualTo(t, 1, len(appValues.Volumes))
+	test.EqualTo(t, "vault-agent", appValues.Sidecars[0].Name)
+	test.EqualTo(t, 1, len(appValues.Rules))
+}
+
+func TestGenerateCapabilitiesFailsForUnknownCapability(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	application := &model.Application{
+		Name:         "busybox",
+		Capabilities: []string{"unknown"},
+	}
+	err := GenerateCapabilitiesFrom(application, provider, &templates.Application{})
+	test.NotNull(t, err)
+	var invalid *model.InvalidReferenceError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "busybox", invalid.App)
+	test.EqualTo(t, "unknown", invalid.Reference)
+	test.EqualTo(t, true, errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,186 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//Environments holds the environment registry of the provider, a nil registry accepts any environment name
+type Environments struct {
+	registry *model.EnvironmentRegistry
+	//byName indexes the environments by name and alias
+	byName map[string]*model.Environment
+}
+
+//Function to load the environment registry of the provider, nil when the provider has no environments manifest
+func LoadEnvironments(provider source.ManifestSource) (*Environments, error) {
+	registry := &model.EnvironmentRegistry{}
+	err := GetEnvironments(registry, provider)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	environments := &Environments{
+		registry: registry,
+		byName:   make(map[string]*model.Environment),
+	}
+	for i := range registry.Environments {
+		env := &registry.Environments[i]
+		if env.Resource == "" {
+			env.Resource = env.Name
+		}
+		if env.Infra == "" {
+			env.Infra = env.Name
+		}
+		for _, name := range append([]string{env.Name}, env.Aliases...) {
+			if name == "" || name == defaultTemplate {
+				return nil, environments.invalid("name", name, "eg: test, sit, prod")
+			}
+			if _, ok := environments.byName[name]; ok {
+				return nil, environments.invalid("name", name, "declared twice")
+			}
+			environments.byName[name] = env
+		}
+	}
+	return environments, nil
+}
+
+//Resolve returns the environment of a name or alias
+func (e *Environments) Resolve(name string) (*model.Environment, error) {
+	env, ok := e.byName[name]
+	if !ok {
+		return nil, &model.UnknownEnvironmentError{
+			Location:    model.Location{Kind: model.KindEnvironments, File: envManifest},
+			Environment: name,
+		}
+	}
+	return env, nil
+}
+
+//Names lists the environment names in promotion order
+func (e *Environments) Names() []string {
+	names := make([]string, 0, len(e.registry.Environments))
+	for _, env := range e.registry.Environments {
+		names = append(names, env.Name)
+	}
+	return names
+}
+
+//Normalize returns a copy of an app manifest with the aliases in its template names, template extends and image
+//mirrors replaced by the environment names, a template or mirror of an undeclared environment is an error and so
+//are two templates of the same environment, eg: test and its alias tst
+func (e *Environments) Normalize(application *model.Application) (*model.Application, error) {
+	canonical := func(field string, name string) (string, error) {
+		if field == "template" && name == defaultTemplate {
+			return name, nil
+		}
+		env, ok := e.byName[name]
+		if !ok {
+			return "", &model.InvalidValueError{
+				Location: model.Location{App: application.Name, Kind: model.KindApplication},
+				Field:    field,
+				Value:    name,
+				Reason:   fmt.Sprintf("not an environment of the provider, eg: %s", strings.Join(e.Names(), ", ")),
+			}
+		}
+		return env.Name, nil
+	}
+	normalized := *application
+	normalized.Template = make([]model.AppTemplate, 0, len(application.Template))
+	declared := make(map[string]string, len(application.Template))
+	for _, tmpl := range application.Template {
+		name, err := canonical("template", tmpl.Name)
+		if err != nil {
+			return nil, err
+		}
+		if first, ok := declared[name]; ok {
+			return nil, &model.InvalidValueError{
+				Location: model.Location{App: application.Name, Kind: model.KindApplication},
+				Field:    "template",
+				Value:    tmpl.Name,
+				Reason:   fmt.Sprintf("templates %s and %s are both environment %s", first, tmpl.Name, name),
+			}
+		}
+		declared[name] = tmpl.Name
+		tmpl.Name = name
+		if tmpl.Extends != "" {
+			extends, err := canonical("template", tmpl.Extends)
+			if err != nil {
+				return nil, err
+			}
+			tmpl.Extends = extends
+		}
+		normalized.Template = append(normalized.Template, tmpl)
+	}
+	if application.Image != nil && len(application.Image.Mirrors) > 0 {
+		image := *application.Image
+		image.Mirrors = make(map[string]string, len(application.Image.Mirrors))
+		names := make([]string, 0, len(application.Image.Mirrors))
+		for name := range application.Image.Mirrors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			env, err := canonical("mirror", name)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := image.Mirrors[env]; ok {
+				return nil, &model.InvalidValueError{
+					Location: model.Location{App: application.Name, Kind: model.KindApplication},
+					Field:    "mirror",
+					Value:    name,
+					Reason:   fmt.Sprintf("environment %s has a mirror already", env),
+				}
+			}
+			image.Mirrors[env] = application.Image.Mirrors[name]
+		}
+		normalized.Image = &image
+	}
+	return &normalized, nil
+}
+
+//canonical returns the environment name of a name or alias
+func (e *Environments) canonical(name string) (string, bool) {
+	env, ok := e.byName[name]
+	if !ok {
+		return "", false
+	}
+	return env.Name, true
+}
+
+//hasResource tells whether an environment uses the resource template of the name
+func (e *Environments) hasResource(name string) bool {
+	for _, env := range e.registry.Environments {
+		if env.Resource == name {
+			return true
+		}
+	}
+	return false
+}
+
+//hasInfra tells whether an environment uses the infrastructure template of the name
+func (e *Environments) hasInfra(name string) bool {
+	for _, env := range e.registry.Environments {
+		if env.Infra == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Environments) invalid(field string, value string, reason string) error {
+	return &model.InvalidValueError{
+		Location: model.Location{Kind: model.KindEnvironments, File: envManifest},
+		Field:    field,
+		Value:    value,
+		Reason:   reason,
+	}
+}
@@ -0,0 +1,164 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+const environmentsManifest = `
+environments:
+  - name: test
+    aliases: [tst]
+    resource: test1
+  - name: prod
+`
+
+func TestLoadEnvironmentsResolvesAliases(t *testing.T) {
+	environments, err := LoadEnvironments(source.Map{envManifest: environmentsManifest})
+	test.Null(t, err)
+	env, err := environments.Resolve("tst")
+	test.Null(t, err)
+	test.EqualTo(t, "test", env.Name)
+	test.EqualTo(t, "test1", env.Resource)
+	test.EqualTo(t, "test", env.Infra)
+	test.EqualTo(t, "test,prod", strings.Join(environments.Names(), ","))
+
+	_, err = environments.Resolve("sit")
+	var unknown *model.UnknownEnvironmentError
+	test.EqualTo(t, true, errors.As(err, &unknown))
+
+	environments, err = LoadEnvironments(source.Map{})
+	test.Null(t, err)
+	test.EqualTo(t, true, environments == nil)
+
+	_, err = LoadEnvironments(source.Map{envManifest: environmentsManifest + "    aliases: [tst]\n"})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "declared twice", invalid.Reason)
+}
+
+func TestNormalizeChecksEnvironments(t *testing.T) {
+	environments, err := LoadEnvironments(source.Map{envManifest: environmentsManifest})
+	test.Null(t, err)
+	application := &model.Application{
+		Name: "api",
+		Template: []model.AppTemplate{
+			{Name: "default"},
+			{Name: "tst"},
+			{Name: "prod", Extends: "tst"},
+		},
+		Image: &model.ImageSpec{Mirrors: map[string]string{"tst": "mirror.test.local"}},
+	}
+	normalized, err := environments.Normalize(application)
+	test.Null(t, err)
+	test.EqualTo(t, "test", normalized.Template[1].Name)
+	test.EqualTo(t, "test", normalized.Template[2].Extends)
+	test.EqualTo(t, "mirror.test.local", normalized.Image.Mirrors["test"])
+	test.EqualTo(t, "tst", application.Template[1].Name)
+	test.EqualTo(t, "tst", application.Template[2].Extends)
+	test.EqualTo(t, "mirror.test.local", application.Image.Mirrors["tst"])
+
+	application.Image.Mirrors["test"] = "mirror.local"
+	_, err = environments.Normalize(application)
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "mirror", invalid.Field)
+
+	application.Image = nil
+	application.Template = append(application.Template, model.AppTemplate{Name: "lab"})
+	_, err = environments.Normalize(application)
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "lab", invalid.Value)
+
+	application.Template = []model.AppTemplate{{Name: "test"}, {Name: "tst"}}
+	_, err = environments.Normalize(application)
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "template", invalid.Field)
+	test.EqualTo(t, "templates test and tst are both environment test", invalid.Reason)
+}
+
+func TestGenerateEnvVarsMapsEnvironmentToResourceTemplate(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	environments, err := LoadEnvironments(provider)
+	test.Null(t, err)
+	sit, err := environments.Resolve("sit")
+	test.Null(t, err)
+	application := &model.Application{Name: "eod-job", Resources: []string{"postgres"}}
+	appValues := &templates.Application{}
+	err = generateEnvVars(application, environments, sit, provider, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "sit_user", appValues.EnvVars["POSTGRES_DATABASE"])
+	test.EqualTo(t, "test-changeit", appValues.SecretEnvVars["POSTGRES_PASSWORD"])
+
+	appValues = &templates.Application{}
+	err = generateEnvVars(application, environments, nil, provider, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(appValues.EnvVars))
+
+	application.Resources = []string{"elasticsearch-account/test"}
+	err = generateEnvVars(application, environments, sit, provider, appValues)
+	var invalid *model.InvalidReferenceError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+}
+
+func TestProcessApplicationWarnsOfDefaultTemplateFallback(t *testing.T) {
+	apps := source.Map{
+		"api.yaml": "name: api\ntemplate:\n  - name: default\n  - name: prod\n",
+	}
+	appValues, err := ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "prdo", apps, source.Map{})
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(appValues.Warnings))
+	test.EqualTo(t, true, strings.Contains(appValues.Warnings[0].Message, "no template for environment prdo"))
+
+	appValues, err = ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "prod", apps, source.Map{})
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(appValues.Warnings))
+
+	_, err = ProcessApplicationFrom(&model.App{Name: "api"}, "release", "apps", "prdo", apps, source.Map{envManifest: environmentsManifest})
+	var unknown *model.UnknownEnvironmentError
+	test.EqualTo(t, true, errors.As(err, &unknown))
+	test.EqualTo(t, "prdo", unknown.Environment)
+}
+
+func TestGenerateEnvVarsChecksResourceTemplateNames(t *testing.T) {
+	provider := source.Map{
+		envManifest:                      environmentsManifest,
+		"resources/db.yaml":              "kind: Resource\nspec:\n  template:\n    - name: test1\n      infrastructure: db-cluster\n    - name: prdo\n",
+		"infrastructure/db-cluster.yaml": "kind: Infrastructure\nspec:\n  template:\n    - name: test\n    - name: tset\n",
+	}
+	environments, err := LoadEnvironments(provider)
+	test.Null(t, err)
+	env, err := environments.Resolve("test")
+	test.Null(t, err)
+	application := &model.Application{Name: "api", Resources: []string{"db"}}
+	appValues := &templates.Application{}
+	err = generateEnvVars(application, environments, env, provider, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(appValues.Warnings))
+	test.EqualTo(t, "template prdo of resource db is not the resource template of an environment", appValues.Warnings[0].Message)
+	test.EqualTo(t, "template tset of infrastructure db-cluster is not the infrastructure template of an environment", appValues.Warnings[1].Message)
+}
+
+func TestGenerateEnvVarsUsesTheInfrastructureOfTheEnvironment(t *testing.T) {
+	provider := source.Map{
+		envManifest:                      "environments:\n  - name: a\n    resource: shared\n    infrastructure: ia\n  - name: b\n    resource: shared\n    infrastructure: ib\n",
+		"resources/db.yaml":              "kind: Resource\nspec:\n  template:\n    - name: shared\n      infrastructure: db-cluster\n",
+		"infrastructure/db-cluster.yaml": "kind: Infrastructure\nspec:\n  template:\n    - name: ia\n      attributes:\n        host: a-host\n    - name: ib\n      attributes:\n        host: b-host\n",
+	}
+	environments, err := LoadEnvironments(provider)
+	test.Null(t, err)
+	application := &model.Application{Name: "api", Resources: []string{"db"}}
+	for name, host := range map[string]string{"a": "a-host", "b": "b-host"} {
+		env, err := environments.Resolve(name)
+		test.Null(t, err)
+		appValues := &templates.Application{}
+		err = generateEnvVars(application, environments, env, provider, appValues)
+		test.Null(t, err)
+		test.EqualTo(t, host, appValues.EnvVars["DB_HOST"])
+	}
+}
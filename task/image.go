@@ -0,0 +1,81 @@
+package task
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	digestPattern   = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	registryPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]{1,5})?(/[a-z0-9]+([._-][a-z0-9]+)*)*/?$`)
+)
+
+//Function to set the image of the app container. The registry of the image is replaced by the mirror of the
+//environment, and the digest of the release spec pins the image over the digest of the app manifest
+func GenerateImage(app *model.App, application *model.Application, environment string, appValues *templates.Application) error {
+	image := application.Image
+	if image == nil {
+		image = &model.ImageSpec{}
+	}
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	repository := image.Repository
+	if repository == "" {
+		repository = app.Name
+	}
+	if strings.ContainsAny(repository, ":@") || strings.ContainsAny(app.Version, ":@/") {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "repository",
+			Value:    repository + ":" + app.Version,
+			Reason:   "the tag is the app version and the digest is set on its own, eg: team/api",
+		}
+	}
+	registry, field := image.Registry, "registry"
+	if mirror, ok := image.Mirrors[environment]; ok && environment != "" {
+		registry, field = mirror, "mirror"
+		setSource(appValues, "registry", mirror, "mirror/"+environment)
+	}
+	if registry != "" && !registryPattern.MatchString(registry) {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    field,
+			Value:    registry,
+			Reason:   "a host with an optional port and path, eg: registry.local:5000/team",
+		}
+	}
+	if registry != "" {
+		repository = strings.TrimSuffix(registry, "/") + "/" + strings.TrimPrefix(repository, "/")
+	}
+
+	digest := image.Digest
+	if app.Digest != "" {
+		digest = app.Digest
+		setSource(appValues, "digest", digest, "release")
+	}
+	if digest != "" && !digestPattern.MatchString(digest) {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "digest",
+			Value:    digest,
+			Reason:   "eg: sha256:<64 hex characters>",
+		}
+	}
+
+	switch image.PullPolicy {
+	case "", "Always", "IfNotPresent", "Never":
+	default:
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "pullPolicy",
+			Value:    image.PullPolicy,
+			Reason:   "eg: Always, IfNotPresent, Never",
+		}
+	}
+	appValues.ImageRepository = repository
+	appValues.ImageDigest = digest
+	appValues.ImagePullPolicy = image.PullPolicy
+	appValues.ImagePullSecrets = image.PullSecrets
+	return nil
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+func TestGenerateImage(t *testing.T) {
+	app := &model.App{Name: "api", Version: "1.0"}
+	application := &model.Application{Name: "api"}
+	appValues := &templates.Application{Name: "api", Tag: "1.0"}
+	err := GenerateImage(app, application, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "api:1.0", appValues.ImageRef())
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	application.Image = &model.ImageSpec{
+		Registry:   "registry.local/",
+		Repository: "team/api",
+		Mirrors:    map[string]string{"prod": "mirror.prod.local"},
+	}
+	app.Digest = digest
+	err = GenerateImage(app, application, "prod", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "mirror.prod.local/team/api:1.0@"+digest, appValues.ImageRef())
+
+	err = GenerateImage(app, application, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "registry.local/team/api:1.0@"+digest, appValues.ImageRef())
+
+	app.Digest = "sha256:abc"
+	err = GenerateImage(app, application, "test", appValues)
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "digest", invalid.Field)
+}
+
+func TestGenerateImageValidatesRegistryAndRepository(t *testing.T) {
+	app := &model.App{Name: "api", Version: "1.0"}
+	application := &model.Application{
+		Name: "api",
+		Image: &model.ImageSpec{
+			Registry:   "registry.local:5000/team",
+			Repository: "api",
+			Mirrors:    map[string]string{"prod": "mirror.prod.local:8443"},
+		},
+	}
+	appValues := &templates.Application{Name: "api", Tag: "1.0"}
+	err := GenerateImage(app, application, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "registry.local:5000/team/api:1.0", appValues.ImageRef())
+	err = GenerateImage(app, application, "prod", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "mirror.prod.local:8443/api:1.0", appValues.ImageRef())
+
+	var invalid *model.InvalidValueError
+	application.Image.Repository = "api:2.0"
+	err = GenerateImage(app, application, "test", appValues)
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "repository", invalid.Field)
+
+	application.Image.Repository = "api"
+	application.Image.Mirrors["prod"] = "mirror.prod.local:port"
+	err = GenerateImage(app, application, "prod", appValues)
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "mirror", invalid.Field)
+	test.EqualTo(t, "mirror.prod.local:port", invalid.Value)
+}
@@ -0,0 +1,168 @@
+package task
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"strings"
+)
+
+//Name of the app template applied to every environment, and to the environments without a template of their own
+const defaultTemplate = "default"
+
+//ResolvedTemplate is the app template of an environment merged with the templates it extends
+type ResolvedTemplate struct {
+	Name string `json:"name"`
+	//Chain lists the merged templates, the most specific first
+	Chain  []string          `json:"chain"`
+	Config map[string]string `json:"config"`
+	//Origin names the template each config key is taken from
+	Origin    map[string]string `json:"origin"`
+	Placement *model.Placement  `json:"placement,omitempty"`
+	//Storage of the volume claims, merged per claim
+	Storage map[string]model.Storage `json:"storage,omitempty"`
+	//StorageOrigin names the template each storage value is taken from, eg: storage/data, storageClass/data
+	StorageOrigin map[string]string `json:"storageOrigin,omitempty"`
+}
+
+//ResolveTemplate merges the app template of the environment with the templates it extends, ending with the
+//default template. The config, placement and storage of a template override those of the template it extends, an environment without
+//a template of its own resolves to the default template
+func ResolveTemplate(application *model.Application, environment string) (*ResolvedTemplate, error) {
+	byName := make(map[string]*model.AppTemplate, len(application.Template))
+	for i := range application.Template {
+		byName[application.Template[i].Name] = &application.Template[i]
+	}
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	name := environment
+	if _, ok := byName[name]; !ok {
+		if _, ok := byName[defaultTemplate]; !ok {
+			return nil, &model.UnknownEnvironmentError{Location: location, Environment: environment}
+		}
+		name = defaultTemplate
+	}
+
+	chain := make([]*model.AppTemplate, 0)
+	visited := make(map[string]bool)
+	for name != "" {
+		if visited[name] {
+			path := make([]string, 0, len(chain)+1)
+			for _, t := range chain {
+				path = append(path, t.Name)
+			}
+			return nil, &model.InvalidReferenceError{
+				Location:  location,
+				Reference: name,
+				Reason:    fmt.Sprintf("circular extends %s -> %s", strings.Join(path, " -> "), name),
+			}
+		}
+		visited[name] = true
+		tmpl := byName[name]
+		chain = append(chain, tmpl)
+		switch {
+		case tmpl.Extends != "":
+			name = tmpl.Extends
+			if _, ok := byName[name]; !ok {
+				return nil, &model.InvalidReferenceError{
+					Location:  location,
+					Reference: name,
+					Reason:    fmt.Sprintf("template %s extends an undefined template", tmpl.Name),
+				}
+			}
+		case tmpl.Name != defaultTemplate && byName[defaultTemplate] != nil:
+			name = defaultTemplate
+		default:
+			name = ""
+		}
+	}
+
+	resolved := &ResolvedTemplate{
+		Name:   environment,
+		Chain:  make([]string, 0, len(chain)),
+		Config: make(map[string]string),
+		Origin: make(map[string]string),
+	}
+	for _, tmpl := range chain {
+		resolved.Chain = append(resolved.Chain, tmpl.Name)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Config {
+			resolved.Config[k] = v
+			resolved.Origin[k] = chain[i].Name
+		}
+		resolved.Placement = mergePlacement(resolved.Placement, chain[i].Placement)
+		for name, storage := range chain[i].Storage {
+			if resolved.Storage == nil {
+				resolved.Storage = make(map[string]model.Storage)
+				resolved.StorageOrigin = make(map[string]string)
+			}
+			merged := resolved.Storage[name]
+			if storage.Size != "" {
+				merged.Size = storage.Size
+				resolved.StorageOrigin["storage/"+name] = chain[i].Name
+			}
+			if storage.StorageClass != "" {
+				merged.StorageClass = storage.StorageClass
+				resolved.StorageOrigin["storageClass/"+name] = chain[i].Name
+			}
+			resolved.Storage[name] = merged
+		}
+	}
+	return resolved, nil
+}
+
+//Function to warn that an environment without a template of its own resolves to the default template, without an
+//environment registry of the provider a misspelt environment is not rejected and renders the default values
+func warnDefaultTemplate(application *model.Application, environment string, appValues *templates.Application) {
+	if environment == "" || environment == defaultTemplate {
+		return
+	}
+	hasDefault := false
+	for _, tmpl := range application.Template {
+		if tmpl.Name == environment {
+			return
+		}
+		hasDefault = hasDefault || tmpl.Name == defaultTemplate
+	}
+	if hasDefault {
+		warn(appValues, model.Location{App: application.Name, Kind: model.KindApplication},
+			"no template for environment %s, applying the default template, declare the environments of the provider to reject unknown names", environment)
+	}
+}
+
+//ResolveTemplates resolves every environment with a template in the app manifest, for review
+func ResolveTemplates(application *model.Application) ([]ResolvedTemplate, error) {
+	resolved := make([]ResolvedTemplate, 0, len(application.Template))
+	for _, tmpl := range application.Template {
+		r, err := ResolveTemplate(application, tmpl.Name)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, *r)
+	}
+	return resolved, nil
+}
+
+//TemplateEnvironments lists the environments an app has a template for, the default template is not an
+//environment of its own but with an environment registry stands for every environment of the registry
+func TemplateEnvironments(application *model.Application, environments *Environments) ([]string, error) {
+	names := make([]string, 0, len(application.Template))
+	for _, tmpl := range application.Template {
+		if tmpl.Name == defaultTemplate {
+			if environments != nil {
+				return environments.Names(), nil
+			}
+			continue
+		}
+		name := tmpl.Name
+		if environments != nil {
+			env, err := environments.Resolve(name)
+			if err != nil {
+				return nil, err
+			}
+			name = env.Name
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
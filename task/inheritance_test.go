@@ -0,0 +1,58 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+func inheritingApplication() *model.Application {
+	return &model.Application{
+		Name: "busybox",
+		Template: []model.AppTemplate{
+			{Name: "default", Config: map[string]string{"cpu": "c05", "memory": "m1", "logging_level": "INFO"}},
+			{Name: "test", Config: map[string]string{"logging_level": "DEBUG"}},
+			{Name: "sit", Extends: "test", Config: map[string]string{"memory": "m2"}},
+			{Name: "prod", Config: map[string]string{"cpu": "c3"}},
+		},
+	}
+}
+
+func TestResolveTemplateMergesExtendedTemplates(t *testing.T) {
+	resolved, err := ResolveTemplate(inheritingApplication(), "sit")
+	test.Null(t, err)
+	test.EqualTo(t, "sit,test,default", strings.Join(resolved.Chain, ","))
+	test.EqualTo(t, "c05", resolved.Config["cpu"])
+	test.EqualTo(t, "m2", resolved.Config["memory"])
+	test.EqualTo(t, "DEBUG", resolved.Config["logging_level"])
+	test.EqualTo(t, "test", resolved.Origin["logging_level"])
+}
+
+func TestResolveTemplateFallsBackToDefault(t *testing.T) {
+	resolved, err := ResolveTemplate(inheritingApplication(), "perf")
+	test.Null(t, err)
+	test.EqualTo(t, "default", strings.Join(resolved.Chain, ","))
+	test.EqualTo(t, "INFO", resolved.Config["logging_level"])
+
+	application := inheritingApplication()
+	application.Template = application.Template[1:]
+	_, err = ResolveTemplate(application, "perf")
+	var unknown *model.UnknownEnvironmentError
+	test.EqualTo(t, true, errors.As(err, &unknown))
+}
+
+func TestResolveTemplateRejectsBrokenExtends(t *testing.T) {
+	application := inheritingApplication()
+	application.Template[1].Extends = "sit"
+	_, err := ResolveTemplate(application, "sit")
+	var invalid *model.InvalidReferenceError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "circular extends sit -> test -> sit", invalid.Reason)
+
+	application.Template[1].Extends = "tset"
+	_, err = ResolveTemplate(application, "test")
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "tset", invalid.Reference)
+}
@@ -0,0 +1,206 @@
+package task
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"sort"
+	"strings"
+)
+
+//List policy of a mixin, decides how its cmd and entrypoint combine with lower salience mixins
+const (
+	listReplace = "replace"
+	listAppend  = "append"
+)
+
+//LoadMixins resolves the mixins of the application from the provider directory, missing mixins are skipped,
+//see LoadMixinsFrom
+func LoadMixins(application *model.Application, resourceDir string) ([]model.Mixin, error) {
+	return LoadMixinsFrom(application, source.Dir(resourceDir), &templates.Application{})
+}
+
+//Function to resolve the mixins referenced by the application, in declaration order.
+//A missing mixin is recorded as a warning of appValues
+func LoadMixinsFrom(application *model.Application, provider source.ManifestSource, appValues *templates.Application) ([]model.Mixin, error) {
+	mixins := make([]model.Mixin, 0)
+	for _, mxin := range application.Mixins {
+		mixinType := strings.Split(mxin, sep)
+		if len(mixinType) < 2 {
+			return nil, &model.InvalidReferenceError{
+				Location:  model.Location{App: application.Name, Kind: model.KindApplication},
+				Reference: mxin,
+				Reason:    "missing mixin name, eg: java/java-default",
+			}
+		}
+		name := mixinType[0]
+		mType := mixinType[1]
+		mixinList := model.MixinList{}
+		err := GetMixinFrom(name, &mixinList, provider)
+		if err != nil {
+			return nil, err
+		}
+		match := false
+		for _, m := range mixinList.Mixin {
+			if mType == m.Name {
+				if m.ListPolicy != "" && m.ListPolicy != listReplace && m.ListPolicy != listAppend {
+					return nil, &model.InvalidValueError{
+						Location: model.Location{App: application.Name, Kind: model.KindMixin, File: fmt.Sprintf(mixinManifest, name)},
+						Field:    "list-policy",
+						Value:    m.ListPolicy,
+						Reason:   "eg: replace, append",
+					}
+				}
+				m.Source = mxin
+				mixins = append(mixins, m)
+				match = true
+				break
+			}
+		}
+		if match == false {
+			location := model.Location{App: application.Name, Kind: model.KindMixin, File: fmt.Sprintf(mixinManifest, name)}
+			warn(appValues, location, "could not find matching mixin %s", mType)
+		}
+	}
+	return mixins, nil
+}
+
+//Function to combine mixins into one.
+//Mixins are applied in ascending salience, declaration order breaks ties, so the value of the
+//highest salience mixin wins. Env maps are merged per key, cmd and entrypoint are replaced
+//unless the mixin declares the append list-policy, probes are replaced as a whole. The returned sources explain the origin of each value.
+func MergeMixins(mixins []model.Mixin) (model.Mixin, []model.ValueSource) {
+	ordered := make([]model.Mixin, len(mixins))
+	copy(ordered, mixins)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Salience < ordered[j].Salience
+	})
+
+	merged := model.Mixin{
+		Env: make(map[string]string, 0),
+	}
+	origin := make(map[string]string, 0)
+	for _, m := range ordered {
+		if m.Cpu != "" {
+			merged.Cpu = m.Cpu
+			origin[cpu] = m.Source
+		}
+		if m.Memory != "" {
+			merged.Memory = m.Memory
+			origin[memory] = m.Source
+		}
+		if m.Replicas != "" {
+			merged.Replicas = m.Replicas
+			origin[replicas] = m.Source
+		}
+		if m.ResourceStrategy != "" {
+			merged.ResourceStrategy = m.ResourceStrategy
+			origin[resourceStrategy] = m.Source
+		}
+		for k, v := range m.Env {
+			merged.Env[k] = v
+			origin[envPrefix+k] = m.Source
+		}
+		if len(m.Cmd) > 0 {
+			merged.Cmd = mergeList(merged.Cmd, m.Cmd, m.ListPolicy)
+			origin[command] = joinSource(origin[command], m.Source, m.ListPolicy)
+		}
+		if len(m.Entrypoint) > 0 {
+			merged.Entrypoint = mergeList(merged.Entrypoint, m.Entrypoint, m.ListPolicy)
+			origin[entrypoint] = joinSource(origin[entrypoint], m.Source, m.ListPolicy)
+		}
+		if m.LivenessProbe != nil {
+			merged.LivenessProbe = m.LivenessProbe
+			origin[livenessProbe] = m.Source
+		}
+		if m.ReadinessProbe != nil {
+			merged.ReadinessProbe = m.ReadinessProbe
+			origin[readinessProbe] = m.Source
+		}
+		if m.StartupProbe != nil {
+			merged.StartupProbe = m.StartupProbe
+			origin[startupProbe] = m.Source
+		}
+	}
+
+	sources := make([]model.ValueSource, 0)
+	addSource := func(field string, value string) {
+		if src, ok := origin[field]; ok {
+			sources = append(sources, model.ValueSource{Field: field, Value: value, Source: src})
+		}
+	}
+	addSource(cpu, merged.Cpu)
+	addSource(memory, merged.Memory)
+	addSource(replicas, merged.Replicas)
+	addSource(resourceStrategy, merged.ResourceStrategy)
+	addSource(command, strings.Join(merged.Cmd, " "))
+	addSource(entrypoint, strings.Join(merged.Entrypoint, " "))
+	addSource(livenessProbe, describeProbe(merged.LivenessProbe))
+	addSource(readinessProbe, describeProbe(merged.ReadinessProbe))
+	addSource(startupProbe, describeProbe(merged.StartupProbe))
+	keys := make([]string, 0, len(merged.Env))
+	for k := range merged.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		addSource(envPrefix+k, merged.Env[k])
+	}
+	return merged, sources
+}
+
+//Function to set env vars, command and entrypoint from the merged mixin
+//mixin env vars are defaults, an env var of a resource or a config of the app template wins
+func GenerateMixins(mixin *model.Mixin, appValues *templates.Application) {
+	if appValues.EnvVars == nil {
+		appValues.EnvVars = make(map[string]string, 0)
+	}
+	for k, v := range mixin.Env {
+		_, inEnv := appValues.EnvVars[k]
+		_, inSecret := appValues.SecretEnvVars[k]
+		_, inConfig := appValues.ConfigEnvVars[k]
+		if inEnv || inSecret || inConfig {
+			dropSource(appValues, envPrefix+k)
+			continue
+		}
+		appValues.EnvVars[k] = v
+	}
+	appValues.Command = append(make([]string, 0), mixin.Cmd...)
+	appValues.Entrypoint = append(make([]string, 0), mixin.Entrypoint...)
+}
+
+func mergeList(current []string, items []string, policy string) []string {
+	if policy == listAppend {
+		return append(append(make([]string, 0), current...), items...)
+	}
+	return items
+}
+
+func joinSource(current string, source string, policy string) string {
+	if policy == listAppend && current != "" {
+		return current + ", " + source
+	}
+	return source
+}
+
+//setSource records the origin of a final value, replacing an earlier origin of the same field
+func setSource(appValues *templates.Application, field string, value string, source string) {
+	for i, s := range appValues.Sources {
+		if s.Field == field {
+			appValues.Sources[i] = model.ValueSource{Field: field, Value: value, Source: source}
+			return
+		}
+	}
+	appValues.Sources = append(appValues.Sources, model.ValueSource{Field: field, Value: value, Source: source})
+}
+
+//dropSource removes the origin of a value that did not make it to the output
+func dropSource(appValues *templates.Application, field string) {
+	for i, s := range appValues.Sources {
+		if s.Field == field {
+			appValues.Sources = append(appValues.Sources[:i], appValues.Sources[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package task
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestMergeMixins(t *testing.T) {
+	mixins := []model.Mixin{
+		{Source: "java/java-default", Cpu: "c1", Env: map[string]string{"A": "1", "B": "1"}, Cmd: []string{"java"}},
+		{Source: "resource-spec/tiny", Cpu: "c05", Env: map[string]string{"B": "2"}},
+	}
+	merged, sources := MergeMixins(mixins)
+	test.EqualTo(t, "c05", merged.Cpu)
+	test.EqualTo(t, "1", merged.Env["A"])
+	test.EqualTo(t, "2", merged.Env["B"])
+	test.EqualTo(t, "java", merged.Cmd[0])
+	test.EqualTo(t, model.ValueSource{Field: "cpu", Value: "c05", Source: "resource-spec/tiny"}, sources[0])
+	test.EqualTo(t, model.ValueSource{Field: "env.B", Value: "2", Source: "resource-spec/tiny"}, sources[len(sources)-1])
+}
+
+func TestMergeMixinsBySalience(t *testing.T) {
+	mixins := []model.Mixin{
+		{Source: "resource-spec/equal-request-limit", ResourceStrategy: "exact", Salience: 100},
+		{Source: "java/java-default", ResourceStrategy: "half", Cmd: []string{"java"}},
+		{Source: "resource-spec/debug", Cmd: []string{"-debug"}, ListPolicy: "append", Salience: 10},
+	}
+	merged, sources := MergeMixins(mixins)
+	test.EqualTo(t, "exact", merged.ResourceStrategy)
+	test.EqualTo(t, 2, len(merged.Cmd))
+	test.EqualTo(t, "-debug", merged.Cmd[1])
+	test.EqualTo(t, model.ValueSource{Field: "resource-limit-strategy", Value: "exact", Source: "resource-spec/equal-request-limit"}, sources[0])
+	test.EqualTo(t, model.ValueSource{Field: "cmd", Value: "java -debug", Source: "java/java-default, resource-spec/debug"}, sources[1])
+}
+
+func TestLoadMixins(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	application := &model.Application{
+		Name:   "api",
+		Mixins: []string{"java/java-default", "resource-spec/equal-request-limit"},
+	}
+	mixins, err := LoadMixinsFrom(application, provider, &templates.Application{})
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(mixins))
+	test.EqualTo(t, 100, mixins[1].Salience)
+	test.EqualTo(t, "java/java-default", mixins[0].Source)
+}
+
+func TestTemplateConfigOverridesMixinSource(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Template: []model.AppTemplate{
+			{Name: "prod", Config: map[string]string{"cpu": "c3"}},
+		},
+	}
+	mixin, sources := MergeMixins([]model.Mixin{{Source: "java/java-default", Cpu: "c1"}})
+	appValues := &templates.Application{Sources: sources}
+	err := GenerateResourceLimit(application, &mixin, DefaultSizes(), "prod", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(appValues.Sources))
+	test.EqualTo(t, "template/prod", appValues.Sources[0].Source)
+	test.EqualTo(t, "c3", appValues.Sources[0].Value)
+}
+
+func TestTemplateConfigOverridesMixinEnv(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Template: []model.AppTemplate{
+			{Name: "prod", Config: map[string]string{"logging_level": "DEBUG"}},
+		},
+	}
+	mixin, sources := MergeMixins([]model.Mixin{{Source: "java/java-default", Env: map[string]string{"LOGGING_LEVEL": "INFO", "JAVA_OPTS": "-Xms1g"}}})
+	appValues := &templates.Application{
+		Sources:       sources,
+		EnvVars:       make(map[string]string, 0),
+		SecretEnvVars: make(map[string]string, 0),
+		ConfigEnvVars: make(map[string]string, 0),
+	}
+	err := GenerateTemplateConfig(application, "prod", appValues)
+	test.Null(t, err)
+	GenerateMixins(&mixin, appValues)
+	test.EqualTo(t, "DEBUG", appValues.EnvVars["LOGGING_LEVEL"])
+	test.EqualTo(t, "-Xms1g", appValues.EnvVars["JAVA_OPTS"])
+	for _, s := range appValues.Sources {
+		if s.Field == "env.LOGGING_LEVEL" {
+			t.Errorf("mixin source of an overridden env var: %v", s)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//Function to set the placement of the pods of the app. The placement policies of the provider for the environment,
+//listed by name or alias, come first, the placement of the app manifest and then the one of its app template override them: node selectors
+//are merged per label, affinities per kind, topology spread constraints per topology key and tolerations are added.
+//A nil environments accepts the environment names of the policies as they are
+func GeneratePlacement(application *model.Application, provider source.ManifestSource, environments *Environments, environment string, appValues *templates.Application) error {
+	policies := &model.PlacementPolicies{}
+	err := GetPlacementPolicies(policies, provider)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var placement *model.Placement
+	applied := make([]string, 0)
+	for i, policy := range policies.Policies {
+		if environments != nil {
+			policy.Environments = make([]string, 0, len(policies.Policies[i].Environments))
+			for _, name := range policies.Policies[i].Environments {
+				env, ok := environments.canonical(name)
+				if !ok {
+					return &model.InvalidValueError{
+						Location: model.Location{App: application.Name, Kind: model.KindPlacement, File: placementManifest},
+						Field:    "environments",
+						Value:    name,
+						Reason:   fmt.Sprintf("policy %s names an undeclared environment, eg: %s", policy.Name, strings.Join(environments.Names(), ", ")),
+					}
+				}
+				policy.Environments = append(policy.Environments, env)
+			}
+		}
+		if appliesTo(policy, environment) {
+			placement = mergePlacement(placement, &policies.Policies[i].Placement)
+			applied = append(applied, policy.Name)
+		}
+	}
+	if len(applied) > 0 {
+		setSource(appValues, "placement", strings.Join(applied, ", "), placementManifest)
+	}
+	placement = mergePlacement(placement, application.Placement)
+	if environment != "" && len(application.Template) > 0 {
+		tmpl, err := ResolveTemplate(application, environment)
+		if err != nil {
+			return err
+		}
+		placement = mergePlacement(placement, tmpl.Placement)
+	}
+	if placement == nil {
+		return nil
+	}
+
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	for i := range placement.TopologySpreadConstraints {
+		constraint := &placement.TopologySpreadConstraints[i]
+		if constraint.TopologyKey == "" {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "topologyKey",
+				Value:    `""`,
+				Reason:   "eg: topology.kubernetes.io/zone",
+			}
+		}
+		if constraint.MaxSkew < 1 {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "maxSkew",
+				Value:    strconv.Itoa(constraint.MaxSkew),
+				Reason:   "must be at least 1",
+			}
+		}
+		if constraint.WhenUnsatisfiable == "" {
+			constraint.WhenUnsatisfiable = "DoNotSchedule"
+		}
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = map[string]interface{}{
+				"matchLabels": map[string]string{"app": appValues.Name, "release": appValues.ReleaseName},
+			}
+		}
+	}
+	for _, toleration := range placement.Tolerations {
+		if toleration.Operator == "Exists" && toleration.Value != "" {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "value",
+				Value:    toleration.Value,
+				Reason:   fmt.Sprintf("a toleration of %s with operator Exists has no value", toleration.Key),
+			}
+		}
+	}
+	appValues.Placement = placement
+	return nil
+}
+
+func appliesTo(policy model.PlacementPolicy, environment string) bool {
+	if len(policy.Environments) == 0 {
+		return true
+	}
+	for _, env := range policy.Environments {
+		if env == environment {
+			return true
+		}
+	}
+	return false
+}
+
+//mergePlacement returns a copy of base overridden by override
+func mergePlacement(base *model.Placement, override *model.Placement) *model.Placement {
+	if override == nil {
+		return base
+	}
+	merged := &model.Placement{}
+	if base != nil {
+		*merged = *base
+	}
+	if len(override.NodeSelector) > 0 {
+		merged.NodeSelector = make(map[string]string, len(merged.NodeSelector)+len(override.NodeSelector))
+		if base != nil {
+			for k, v := range base.NodeSelector {
+				merged.NodeSelector[k] = v
+			}
+		}
+		for k, v := range override.NodeSelector {
+			merged.NodeSelector[k] = v
+		}
+	}
+	if len(override.Affinity) > 0 {
+		merged.Affinity = make(map[string]interface{}, len(merged.Affinity)+len(override.Affinity))
+		if base != nil {
+			for k, v := range base.Affinity {
+				merged.Affinity[k] = v
+			}
+		}
+		for k, v := range override.Affinity {
+			merged.Affinity[k] = stringKeys(v)
+		}
+	}
+	merged.Tolerations = append(make([]model.Toleration, 0), merged.Tolerations...)
+	for _, toleration := range override.Tolerations {
+		if !containsToleration(merged.Tolerations, toleration) {
+			merged.Tolerations = append(merged.Tolerations, toleration)
+		}
+	}
+	merged.TopologySpreadConstraints = append(make([]model.TopologySpreadConstraint, 0), merged.TopologySpreadConstraints...)
+	for _, constraint := range override.TopologySpreadConstraints {
+		if constraint.LabelSelector != nil {
+			constraint.LabelSelector = stringKeys(constraint.LabelSelector).(map[string]interface{})
+		}
+		replaced := false
+		for i, c := range merged.TopologySpreadConstraints {
+			if c.TopologyKey == constraint.TopologyKey {
+				merged.TopologySpreadConstraints[i] = constraint
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged.TopologySpreadConstraints = append(merged.TopologySpreadConstraints, constraint)
+		}
+	}
+	return merged
+}
+
+func containsToleration(tolerations []model.Toleration, toleration model.Toleration) bool {
+	for _, t := range tolerations {
+		if t == toleration {
+			return true
+		}
+	}
+	return false
+}
+
+//stringKeys converts the maps decoded from yaml into string keyed maps, so the placement can be printed as json
+func stringKeys(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			converted[fmt.Sprint(k)] = stringKeys(v)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			converted[k] = stringKeys(v)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(typed))
+		for i, v := range typed {
+			converted[i] = stringKeys(v)
+		}
+		return converted
+	}
+	return value
+}
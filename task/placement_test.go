@@ -0,0 +1,94 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+const placementPolicies = `
+policies:
+  - name: linux
+    placement:
+      nodeSelector:
+        kubernetes.io/os: linux
+  - name: zone-spread
+    environments: [prod]
+    placement:
+      topologySpreadConstraints:
+        - maxSkew: 1
+          topologyKey: topology.kubernetes.io/zone
+`
+
+func TestGeneratePlacementMergesPoliciesAppAndTemplate(t *testing.T) {
+	provider := source.Map{placementManifest: placementPolicies}
+	application := &model.Application{
+		Name: "api",
+		Placement: &model.Placement{
+			NodeSelector: map[string]string{"pool": "web"},
+			Tolerations:  []model.Toleration{{Key: "dedicated", Operator: "Exists", Effect: "NoSchedule"}},
+		},
+		Template: []model.AppTemplate{
+			{Name: "test"},
+			{Name: "prod", Placement: &model.Placement{
+				NodeSelector:              map[string]string{"pool": "web-prod"},
+				TopologySpreadConstraints: []model.TopologySpreadConstraint{{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone"}},
+			}},
+		},
+	}
+	appValues := &templates.Application{Name: "api", ReleaseName: "apps"}
+	err := GeneratePlacement(application, provider, nil, "prod", appValues)
+	test.Null(t, err)
+	placement := appValues.Placement
+	test.EqualTo(t, "linux", placement.NodeSelector["kubernetes.io/os"])
+	test.EqualTo(t, "web-prod", placement.NodeSelector["pool"])
+	test.EqualTo(t, 1, len(placement.Tolerations))
+	test.EqualTo(t, 1, len(placement.TopologySpreadConstraints))
+	test.EqualTo(t, 2, placement.TopologySpreadConstraints[0].MaxSkew)
+	test.EqualTo(t, "DoNotSchedule", placement.TopologySpreadConstraints[0].WhenUnsatisfiable)
+	test.NotNull(t, placement.TopologySpreadConstraints[0].LabelSelector["matchLabels"])
+	test.EqualTo(t, "web", application.Placement.NodeSelector["pool"])
+
+	appValues = &templates.Application{Name: "api", ReleaseName: "apps"}
+	err = GeneratePlacement(application, provider, nil, "test", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(appValues.Placement.TopologySpreadConstraints))
+}
+
+func TestGeneratePlacementRejectsInvalidSpread(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Placement: &model.Placement{
+			TopologySpreadConstraints: []model.TopologySpreadConstraint{{TopologyKey: "kubernetes.io/hostname"}},
+		},
+	}
+	err := GeneratePlacement(application, source.Map{}, nil, "prod", &templates.Application{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "maxSkew", invalid.Field)
+}
+
+func TestGeneratePlacementResolvesPolicyEnvironments(t *testing.T) {
+	application := &model.Application{Name: "api"}
+	provider := source.Map{
+		placementManifest: strings.Replace(placementPolicies, "[prod]", "[production]", 1),
+		envManifest:       "environments:\n  - name: prod\n    aliases: [production]\n",
+	}
+	environments, err := LoadEnvironments(provider)
+	test.Null(t, err)
+	appValues := &templates.Application{Name: "api", ReleaseName: "apps"}
+	err = GeneratePlacement(application, provider, environments, "prod", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(appValues.Placement.TopologySpreadConstraints))
+
+	provider[placementManifest] = strings.Replace(placementPolicies, "[prod]", "[prdo]", 1)
+	err = GeneratePlacement(application, provider, environments, "prod", &templates.Application{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "prdo", invalid.Value)
+	test.EqualTo(t, placementManifest, invalid.File)
+}
@@ -0,0 +1,170 @@
+package task
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"strconv"
+)
+
+const (
+	livenessProbe  = "liveness_probe"
+	readinessProbe = "readiness_probe"
+	startupProbe   = "startup_probe"
+	appSource      = "app"
+)
+
+//Function to set the probes of the app container.
+//A probe of the app manifest overlays the probe of the merged mixin: its check action replaces the one of the mixin
+//and its timings replace those it sets, so a mixin can supply probe defaults for a family of apps.
+//A Job or CronJob runs to completion and has no probes
+func GenerateProbes(application *model.Application, mixin *model.Mixin, appValues *templates.Application) error {
+	if kind := workloadKind(appValues); kind == "Job" || kind == "CronJob" {
+		if application.LivenessProbe != nil || application.ReadinessProbe != nil || application.StartupProbe != nil {
+			warn(appValues, model.Location{App: application.Name, Kind: model.KindApplication}, "probes are ignored by kind %s", kind)
+		}
+		return nil
+	}
+	probes := []struct {
+		field  string
+		app    *model.Probe
+		mixin  *model.Probe
+		target **model.Probe
+	}{
+		{livenessProbe, application.LivenessProbe, mixin.LivenessProbe, &appValues.LivenessProbe},
+		{readinessProbe, application.ReadinessProbe, mixin.ReadinessProbe, &appValues.ReadinessProbe},
+		{startupProbe, application.StartupProbe, mixin.StartupProbe, &appValues.StartupProbe},
+	}
+	for _, p := range probes {
+		probe := mergeProbe(p.mixin, p.app)
+		if probe == nil {
+			continue
+		}
+		kind := model.KindApplication
+		if p.app == nil {
+			kind = model.KindMixin
+		}
+		location := model.Location{App: application.Name, Kind: kind}
+		if probe.Actions() != 1 {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    p.field,
+				Value:    fmt.Sprintf("%d actions", probe.Actions()),
+				Reason:   "a probe has one of httpGet, tcpSocket, exec, grpc",
+			}
+		}
+		if (probe.HttpGet != nil && probe.HttpGet.Port == "" || probe.TcpSocket != nil && probe.TcpSocket.Port == "") && appValues.ProbePort == "" {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "port",
+				Value:    `""`,
+				Reason:   "the app declares no port to probe, eg: port: 8080",
+			}
+		}
+		if probe.HttpGet != nil && probe.HttpGet.Port == "" {
+			probe.HttpGet.Port = appValues.ProbePort
+		}
+		if probe.TcpSocket != nil && probe.TcpSocket.Port == "" {
+			probe.TcpSocket.Port = appValues.ProbePort
+		}
+		if probe.Grpc != nil && (probe.Grpc.Port < 1 || probe.Grpc.Port > 65535) {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "port",
+				Value:    strconv.Itoa(probe.Grpc.Port),
+				Reason:   "a grpc probe requires a port number, eg: 1-65535",
+			}
+		}
+		if probe.Exec != nil && len(probe.Exec.Command) == 0 {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "command",
+				Value:    "[]",
+				Reason:   "an exec probe requires a command",
+			}
+		}
+		if p.field != readinessProbe && probe.SuccessThreshold > 1 {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "successThreshold",
+				Value:    strconv.Itoa(probe.SuccessThreshold),
+				Reason:   "must be 1 for liveness and startup probes",
+			}
+		}
+		if p.app != nil {
+			setSource(appValues, p.field, describeProbe(probe), appSource)
+		}
+		*p.target = probe
+	}
+	return nil
+}
+
+//mergeProbe returns a copy of base overlaid by override
+func mergeProbe(base *model.Probe, override *model.Probe) *model.Probe {
+	if base == nil && override == nil {
+		return nil
+	}
+	merged := model.Probe{}
+	if base != nil {
+		merged = copyProbe(base)
+	}
+	if override == nil {
+		return &merged
+	}
+	o := copyProbe(override)
+	if o.Actions() > 0 {
+		merged.HttpGet, merged.TcpSocket, merged.Exec, merged.Grpc = o.HttpGet, o.TcpSocket, o.Exec, o.Grpc
+	}
+	for _, timing := range []struct{ value, target *int }{
+		{&o.InitialDelaySeconds, &merged.InitialDelaySeconds},
+		{&o.PeriodSeconds, &merged.PeriodSeconds},
+		{&o.TimeoutSeconds, &merged.TimeoutSeconds},
+		{&o.SuccessThreshold, &merged.SuccessThreshold},
+		{&o.FailureThreshold, &merged.FailureThreshold},
+		{&o.TerminationGracePeriodSeconds, &merged.TerminationGracePeriodSeconds},
+	} {
+		if *timing.value != 0 {
+			*timing.target = *timing.value
+		}
+	}
+	return &merged
+}
+
+//copyProbe copies the actions of a probe too, so the ports defaulted for one app do not leak into a shared mixin
+func copyProbe(probe *model.Probe) model.Probe {
+	c := *probe
+	if probe.HttpGet != nil {
+		httpGet := *probe.HttpGet
+		c.HttpGet = &httpGet
+	}
+	if probe.TcpSocket != nil {
+		tcpSocket := *probe.TcpSocket
+		c.TcpSocket = &tcpSocket
+	}
+	if probe.Exec != nil {
+		exec := *probe.Exec
+		c.Exec = &exec
+	}
+	if probe.Grpc != nil {
+		grpc := *probe.Grpc
+		c.Grpc = &grpc
+	}
+	return c
+}
+
+//describeProbe summarises the action of a probe for the value sources, eg: httpGet /health
+func describeProbe(probe *model.Probe) string {
+	switch {
+	case probe == nil:
+		return ""
+	case probe.HttpGet != nil:
+		return fmt.Sprintf("httpGet %s", probe.HttpGet.Path)
+	case probe.TcpSocket != nil:
+		return fmt.Sprintf("tcpSocket %s", probe.TcpSocket.Port)
+	case probe.Exec != nil:
+		return fmt.Sprintf("exec %v", probe.Exec.Command)
+	case probe.Grpc != nil:
+		return fmt.Sprintf("grpc %d", probe.Grpc.Port)
+	}
+	return "no action"
+}
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestGenerateProbesOverlaysMixinProbes(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	application := &model.Application{
+		Name:          "api",
+		Mixins:        []string{"java/java-microservices"},
+		LivenessProbe: &model.Probe{InitialDelaySeconds: 60},
+		ReadinessProbe: &model.Probe{
+			HttpGet: &model.HttpGetAction{Path: "/ready", Port: "admin"},
+		},
+	}
+	appValues := &templates.Application{ProbePort: "http"}
+	mixins, err := LoadMixinsFrom(application, provider, appValues)
+	test.Null(t, err)
+	mixin, _ := MergeMixins(mixins)
+	err = GenerateProbes(application, &mixin, appValues)
+	test.Null(t, err)
+
+	test.EqualTo(t, "/actuator/health/liveness", appValues.LivenessProbe.HttpGet.Path)
+	test.EqualTo(t, "http", appValues.LivenessProbe.HttpGet.Port)
+	test.EqualTo(t, 60, appValues.LivenessProbe.InitialDelaySeconds)
+	test.EqualTo(t, 10, appValues.LivenessProbe.PeriodSeconds)
+	test.EqualTo(t, "/ready", appValues.ReadinessProbe.HttpGet.Path)
+	test.EqualTo(t, "admin", appValues.ReadinessProbe.HttpGet.Port)
+	test.EqualTo(t, 30, appValues.StartupProbe.FailureThreshold)
+	test.EqualTo(t, "", mixin.LivenessProbe.HttpGet.Port)
+}
+
+func TestGenerateProbesRejectsInvalidProbes(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		LivenessProbe: &model.Probe{
+			HttpGet:   &model.HttpGetAction{Path: "/health"},
+			TcpSocket: &model.TcpSocketAction{},
+		},
+	}
+	err := GenerateProbes(application, &model.Mixin{}, &templates.Application{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "liveness_probe", invalid.Field)
+
+	application.LivenessProbe = &model.Probe{Grpc: &model.GrpcAction{}}
+	err = GenerateProbes(application, &model.Mixin{}, &templates.Application{})
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "port", invalid.Field)
+}
+
+func TestGenerateProbesRequiresAPortToProbe(t *testing.T) {
+	application := &model.Application{
+		Name:          "worker",
+		LivenessProbe: &model.Probe{TcpSocket: &model.TcpSocketAction{}},
+	}
+	err := GenerateProbes(application, &model.Mixin{}, &templates.Application{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "port", invalid.Field)
+
+	application.LivenessProbe.TcpSocket.Port = "9000"
+	err = GenerateProbes(application, &model.Mixin{}, &templates.Application{})
+	test.Null(t, err)
+}
+
+func TestGenerateProbesSkipsJobs(t *testing.T) {
+	application := &model.Application{
+		Name:          "report",
+		Kind:          "CronJob",
+		LivenessProbe: &model.Probe{TcpSocket: &model.TcpSocketAction{}},
+	}
+	mixin := &model.Mixin{ReadinessProbe: &model.Probe{HttpGet: &model.HttpGetAction{Path: "/ready"}}}
+	appValues := &templates.Application{Kind: "CronJob"}
+	err := GenerateProbes(application, mixin, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, true, appValues.LivenessProbe == nil)
+	test.EqualTo(t, true, appValues.ReadinessProbe == nil)
+	test.EqualTo(t, 1, len(appValues.Warnings))
+}
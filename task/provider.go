@@ -3,25 +3,77 @@ package task
 import (
 	"fmt"
 	"github.com/kube-sailmaker/template-gen/functions"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
 )
 
 const (
-	infraManifest    = "%s/infrastructure/%s.yaml"
-	mixinManifest    = "%s/mixins/%s.yaml"
-	resourceManifest = "%s/resources/%s.yaml"
+	appManifest        = "%s.yaml"
+	capabilityManifest = "capabilities/%s.yaml"
+	infraManifest      = "infrastructure/%s.yaml"
+	mixinManifest      = "mixins/%s.yaml"
+	resourceManifest   = "resources/%s.yaml"
+	sizesManifest      = "sizes.yaml"
+	envManifest        = "environments.yaml"
+	placementManifest  = "placement.yaml"
 )
 
+//GetCapability reads a capability from the provider directory, see GetCapabilityFrom
+func GetCapability(name string, t interface{}, resourceDir string) error {
+	return GetCapabilityFrom(name, t, source.Dir(resourceDir))
+}
+
+func GetCapabilityFrom(name string, t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindCapability, fmt.Sprintf(capabilityManifest, name), t)
+	return err
+}
+
+//GetInfrastructure reads an infrastructure from the provider directory, see GetInfrastructureFrom
 func GetInfrastructure(name string, t interface{}, resourceDir string) error {
-	file := fmt.Sprintf(infraManifest, resourceDir, name)
-	return functions.UnmarshalFile(file, t)
+	return GetInfrastructureFrom(name, t, source.Dir(resourceDir))
 }
 
+func GetInfrastructureFrom(name string, t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindInfrastructure, fmt.Sprintf(infraManifest, name), t)
+	return err
+}
+
+//GetMixin reads a mixin list from the provider directory, see GetMixinFrom
 func GetMixin(name string, t interface{}, resourceDir string) error {
-	file := fmt.Sprintf(mixinManifest, resourceDir, name)
-	return functions.UnmarshalFile(file, t)
+	return GetMixinFrom(name, t, source.Dir(resourceDir))
+}
+
+func GetMixinFrom(name string, t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindMixin, fmt.Sprintf(mixinManifest, name), t)
+	return err
 }
 
+//GetResource reads a resource from the provider directory, see GetResourceFrom
 func GetResource(name string, t interface{}, resourceDir string) error {
-	file := fmt.Sprintf(resourceManifest, resourceDir, name)
-	return functions.UnmarshalFile(file, t)
+	return GetResourceFrom(name, t, source.Dir(resourceDir))
+}
+
+func GetResourceFrom(name string, t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindResource, fmt.Sprintf(resourceManifest, name), t)
+	return err
+}
+
+//GetSizes reads the size catalogue from the provider directory, see GetSizesFrom
+func GetSizes(t interface{}, resourceDir string) error {
+	return GetSizesFrom(t, source.Dir(resourceDir))
+}
+
+func GetSizesFrom(t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindSizes, sizesManifest, t)
+	return err
+}
+
+func GetEnvironments(t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindEnvironments, envManifest, t)
+	return err
+}
+
+func GetPlacementPolicies(t interface{}, provider source.ManifestSource) error {
+	_, err := functions.UnmarshalManifest(provider, model.KindPlacement, placementManifest, t)
+	return err
 }
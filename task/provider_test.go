@@ -2,14 +2,15 @@ package task
 
 import (
 	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
 	"github.com/kube-sailmaker/template-gen/test"
 	"testing"
 )
 
 func TestGetResource(t *testing.T) {
-	resourceDir := "../sample-manifest/provider"
+	provider := source.Dir("../sample-manifest/provider")
 	resource := &model.Resource{}
-	err := GetResource("cassandra", resource, resourceDir)
+	err := GetResourceFrom("cassandra", resource, provider)
 	test.Null(t, err)
 	test.NotNull(t, resource)
 	test.EqualTo(t, "Resource", resource.Kind)
@@ -19,9 +20,9 @@ func TestGetResource(t *testing.T) {
 }
 
 func TestGetInfrastructure(t *testing.T) {
-	resourceDir := "../sample-manifest/provider"
+	provider := source.Dir("../sample-manifest/provider")
 	infrastructure := &model.Infrastructure{}
-	err := GetInfrastructure("cassandra-cluster-a", infrastructure, resourceDir)
+	err := GetInfrastructureFrom("cassandra-cluster-a", infrastructure, provider)
 	test.Null(t, err)
 	test.NotNull(t, infrastructure)
 	test.EqualTo(t, "v1", infrastructure.ApiVersion)
@@ -31,10 +32,20 @@ func TestGetInfrastructure(t *testing.T) {
 }
 
 func TestGetMixin(t *testing.T) {
-	resourceDir := "../sample-manifest/provider"
+	provider := source.Dir("../sample-manifest/provider")
 	mixinList := &model.MixinList{}
-	err := GetMixin("java", mixinList, resourceDir)
+	err := GetMixinFrom("java", mixinList, provider)
 	test.Null(t, err)
 	test.NotNull(t, mixinList)
 	test.EqualTo(t, "java-default", mixinList.Mixin[0].Name)
 }
+
+func TestGetCapability(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	capability := &model.Capability{}
+	err := GetCapabilityFrom("read-kubernetes", capability, provider)
+	test.Null(t, err)
+	test.EqualTo(t, "Capability", capability.Kind)
+	test.EqualTo(t, "read-kubernetes", capability.Metadata["name"])
+	test.EqualTo(t, "pods", capability.Spec.Rules[0].Resources[0])
+}
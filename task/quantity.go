@@ -0,0 +1,56 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+var quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(m|k|M|G|T|Ki|Mi|Gi|Ti)?$`)
+
+var binarySuffix = map[string]float64{
+	"Ki": 1,
+	"Mi": 1024,
+	"Gi": 1024 * 1024,
+	"Ti": 1024 * 1024 * 1024,
+}
+
+var decimalSuffix = map[string]float64{
+	"k": 1,
+	"M": 1000,
+	"G": 1000 * 1000,
+	"T": 1000 * 1000 * 1000,
+}
+
+//halfQuantity divides a kubernetes quantity by two,
+//cpu is expressed in millicores and memory in Mi (or Ki/k when smaller)
+func halfQuantity(quantity string) (string, error) {
+	match := quantityPattern.FindStringSubmatch(quantity)
+	if match == nil {
+		return "", fmt.Errorf("invalid quantity %q", quantity)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid quantity %q", quantity)
+	}
+	suffix := match[2]
+	switch {
+	case suffix == "":
+		return fmt.Sprintf("%dm", int64(math.Round(value*1000/2))), nil
+	case suffix == "m":
+		return fmt.Sprintf("%dm", int64(math.Round(value/2))), nil
+	case binarySuffix[suffix] > 0:
+		ki := int64(math.Round(value * binarySuffix[suffix] / 2))
+		if ki%1024 == 0 {
+			return fmt.Sprintf("%dMi", ki/1024), nil
+		}
+		return fmt.Sprintf("%dKi", ki), nil
+	default:
+		k := int64(math.Round(value * decimalSuffix[suffix] / 2))
+		if k%1000 == 0 {
+			return fmt.Sprintf("%dM", k/1000), nil
+		}
+		return fmt.Sprintf("%dk", k), nil
+	}
+}
@@ -0,0 +1,134 @@
+package task
+
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"regexp"
+	"strconv"
+)
+
+const (
+	defaultPortName      = "http"
+	defaultServicePort   = 80
+	defaultContainerPort = 8080
+	serviceHeadless      = "headless"
+)
+
+//Port names are referenced by the service and the probes, eg: http, grpc-web
+var portNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?$`)
+
+//Function to set the service and container ports of the app.
+//Without a service spec the app serves http on container port 8080, unless it is a Job or CronJob running to completion
+//without a service. A disabled service still declares the listed container ports
+func GenerateService(application *model.Application, appValues *templates.Application) error {
+	spec := application.Service
+	if spec == nil {
+		kind := workloadKind(appValues)
+		spec = &model.ServiceSpec{Enabled: kind != "Job" && kind != "CronJob"}
+	}
+	ports := spec.Ports
+	if len(ports) > 0 && spec.Port != 0 {
+		return invalidPort(model.Location{App: application.Name, Kind: model.KindApplication}, "port", spec.Port,
+			"conflicts with ports, declare it as the containerPort of a port")
+	}
+	if len(ports) == 0 && spec.Enabled {
+		containerPort := spec.Port
+		if containerPort < 0 || containerPort > 65535 {
+			return invalidPort(model.Location{App: application.Name, Kind: model.KindApplication}, "port", containerPort, "eg: 1-65535")
+		}
+		if containerPort == 0 {
+			containerPort = defaultContainerPort
+		}
+		ports = []model.ServicePort{{Name: defaultPortName, Port: defaultServicePort, ContainerPort: containerPort}}
+	}
+
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	names := make(map[string]bool, len(ports))
+	values := make([]templates.Port, 0, len(ports))
+	for _, port := range ports {
+		if !portNamePattern.MatchString(port.Name) || names[port.Name] {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "name",
+				Value:    port.Name,
+				Reason:   "port names are unique, lower case and up to 15 characters, eg: http, grpc-web",
+			}
+		}
+		names[port.Name] = true
+		if port.Port == 0 {
+			port.Port = port.ContainerPort
+		}
+		if port.Protocol == "" {
+			port.Protocol = "TCP"
+		}
+		if port.ContainerPort < 1 || port.ContainerPort > 65535 {
+			return invalidPort(location, "containerPort", port.ContainerPort, "eg: 1-65535")
+		}
+		if port.Port < 1 || port.Port > 65535 {
+			return invalidPort(location, "port", port.Port, "eg: 1-65535")
+		}
+		if port.NodePort != 0 {
+			if spec.Type != "NodePort" && spec.Type != "LoadBalancer" {
+				return invalidPort(location, "nodePort", port.NodePort, "only a NodePort or LoadBalancer service has node ports")
+			}
+			if port.NodePort < 30000 || port.NodePort > 32767 {
+				return invalidPort(location, "nodePort", port.NodePort, "eg: 30000-32767")
+			}
+		}
+		values = append(values, templates.Port{
+			Name:          port.Name,
+			Protocol:      port.Protocol,
+			Port:          port.Port,
+			ContainerPort: port.ContainerPort,
+			AppProtocol:   port.AppProtocol,
+			NodePort:      port.NodePort,
+		})
+	}
+
+	appValues.Ports = values
+	appValues.ContainerPort = 0
+	appValues.ProbePort = ""
+	if len(values) > 0 {
+		appValues.ContainerPort = values[0].ContainerPort
+		appValues.ProbePort = values[0].Name
+	}
+	if names[defaultPortName] {
+		appValues.ProbePort = defaultPortName
+	}
+	appValues.ServiceEnabled = spec.Enabled
+	appValues.ServiceAnnotations = spec.Annotations
+	switch spec.Type {
+	case "":
+		appValues.ServiceType = "ClusterIP"
+	case serviceHeadless:
+		appValues.ServiceType = "ClusterIP"
+		appValues.Headless = true
+	case "ClusterIP", "NodePort", "LoadBalancer":
+		appValues.ServiceType = spec.Type
+	default:
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "type",
+			Value:    spec.Type,
+			Reason:   "eg: ClusterIP, NodePort, LoadBalancer, headless",
+		}
+	}
+	if spec.Enabled && len(values) == 0 {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "enabled",
+			Value:    "true",
+			Reason:   "a service requires a port",
+		}
+	}
+	return nil
+}
+
+func invalidPort(location model.Location, field string, value int, reason string) error {
+	return &model.InvalidValueError{
+		Location: location,
+		Field:    field,
+		Value:    strconv.Itoa(value),
+		Reason:   reason,
+	}
+}
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestGenerateServiceDefaultsToHttp(t *testing.T) {
+	appValues := &templates.Application{}
+	err := GenerateService(&model.Application{Name: "api"}, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, true, appValues.ServiceEnabled)
+	test.EqualTo(t, "ClusterIP", appValues.ServiceType)
+	test.EqualTo(t, 1, len(appValues.Ports))
+	test.EqualTo(t, templates.Port{Name: "http", Protocol: "TCP", Port: 80, ContainerPort: 8080}, appValues.Ports[0])
+	test.EqualTo(t, "http", appValues.ProbePort)
+	test.EqualTo(t, 8080, appValues.ContainerPort)
+}
+
+func TestGenerateServiceWithPorts(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Service: &model.ServiceSpec{
+			Enabled: true,
+			Type:    "headless",
+			Ports: []model.ServicePort{
+				{Name: "grpc", ContainerPort: 9090, AppProtocol: "grpc"},
+				{Name: "metrics", Port: 9100, ContainerPort: 9100, Protocol: "TCP"},
+			},
+		},
+	}
+	appValues := &templates.Application{}
+	err := GenerateService(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, true, appValues.Headless)
+	test.EqualTo(t, 9090, appValues.Ports[0].Port)
+	test.EqualTo(t, "TCP", appValues.Ports[0].Protocol)
+	test.EqualTo(t, "grpc", appValues.ProbePort)
+
+	application.Service.Ports[1].NodePort = 30100
+	err = GenerateService(application, appValues)
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "nodePort", invalid.Field)
+
+	application.Service.Ports[1].NodePort = 0
+	application.Service.Ports[1].Name = "grpc"
+	err = GenerateService(application, appValues)
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "name", invalid.Field)
+}
+
+func TestGenerateServicePortsConflictWithPort(t *testing.T) {
+	application := &model.Application{
+		Name: "api",
+		Service: &model.ServiceSpec{
+			Enabled: true,
+			Port:    8081,
+			Ports:   []model.ServicePort{{Name: "web", ContainerPort: 9090}},
+		},
+	}
+	err := GenerateService(application, &templates.Application{})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "port", invalid.Field)
+	test.EqualTo(t, "8081", invalid.Value)
+
+	application.Service.Port = 0
+	appValues := &templates.Application{}
+	err = GenerateService(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 9090, appValues.ContainerPort)
+	test.EqualTo(t, "web", appValues.ProbePort)
+
+	application.Service = &model.ServiceSpec{}
+	err = GenerateService(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 0, appValues.ContainerPort)
+	test.EqualTo(t, "", appValues.ProbePort)
+}
+
+func TestGenerateServiceDisabledForJobs(t *testing.T) {
+	for _, kind := range []string{"Job", "CronJob"} {
+		appValues := &templates.Application{Kind: kind}
+		err := GenerateService(&model.Application{Name: "report", Kind: kind}, appValues)
+		test.Null(t, err)
+		test.EqualTo(t, false, appValues.ServiceEnabled)
+		test.EqualTo(t, 0, len(appValues.Ports))
+	}
+
+	application := &model.Application{
+		Name:    "report",
+		Kind:    "CronJob",
+		Service: &model.ServiceSpec{Enabled: true},
+	}
+	appValues := &templates.Application{Kind: "CronJob"}
+	err := GenerateService(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, true, appValues.ServiceEnabled)
+	test.EqualTo(t, 8080, appValues.ContainerPort)
+}
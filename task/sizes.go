@@ -0,0 +1,121 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"io/fs"
+)
+
+const defaultSize = "default"
+
+//Sizes holds the size classes resolved for one environment
+type Sizes struct {
+	Cpu    map[string]string
+	Memory map[string]string
+}
+
+//DefaultSizes returns the built-in CPU and MEMORY classes, used when the provider has no sizes manifest
+func DefaultSizes() *Sizes {
+	return &Sizes{
+		Cpu:    copyMap(CPU),
+		Memory: copyMap(MEMORY),
+	}
+}
+
+//LoadSizes loads the size catalogue of the provider directory, see LoadSizesFrom
+func LoadSizes(resourceDir string, environment string) (*Sizes, error) {
+	return LoadSizesFrom(source.Dir(resourceDir), environment)
+}
+
+//Function to load the size catalogue of the provider and apply the overrides of the environment
+func LoadSizesFrom(provider source.ManifestSource, environment string) (*Sizes, error) {
+	catalogue := &model.SizeCatalogue{}
+	err := GetSizesFrom(catalogue, provider)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultSizes(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sizes := &Sizes{
+		Cpu:    copyMap(catalogue.Cpu),
+		Memory: copyMap(catalogue.Memory),
+	}
+	for _, override := range catalogue.Environments {
+		if override.Name == environment {
+			for k, v := range override.Cpu {
+				sizes.Cpu[k] = v
+			}
+			for k, v := range override.Memory {
+				sizes.Memory[k] = v
+			}
+		}
+	}
+	if _, ok := sizes.Cpu[defaultSize]; !ok {
+		sizes.Cpu[defaultSize] = CPU[defaultSize]
+	}
+	if _, ok := sizes.Memory[defaultSize]; !ok {
+		sizes.Memory[defaultSize] = MEMORY[defaultSize]
+	}
+	return sizes, sizes.validate()
+}
+
+//ResolveCpu returns the quantity of a cpu class, a raw quantity such as 250m is returned as is
+func (s *Sizes) ResolveCpu(class string) (string, error) {
+	return resolveSize(s.Cpu, cpu, class)
+}
+
+//ResolveMemory returns the quantity of a memory class, a raw quantity such as 512Mi is returned as is
+func (s *Sizes) ResolveMemory(class string) (string, error) {
+	return resolveSize(s.Memory, memory, class)
+}
+
+func (s *Sizes) validate() error {
+	for k, v := range s.Cpu {
+		if !quantityPattern.MatchString(v) {
+			return &model.InvalidValueError{
+				Location: model.Location{Kind: model.KindSizes, File: sizesManifest},
+				Field:    "cpu." + k,
+				Value:    v,
+				Reason:   "not a quantity, eg: 250m",
+			}
+		}
+	}
+	for k, v := range s.Memory {
+		if !quantityPattern.MatchString(v) {
+			return &model.InvalidValueError{
+				Location: model.Location{Kind: model.KindSizes, File: sizesManifest},
+				Field:    "memory." + k,
+				Value:    v,
+				Reason:   "not a quantity, eg: 512Mi",
+			}
+		}
+	}
+	return nil
+}
+
+func resolveSize(classes map[string]string, kind string, class string) (string, error) {
+	if class == "" {
+		class = defaultSize
+	}
+	if val, ok := classes[class]; ok {
+		return val, nil
+	}
+	if quantityPattern.MatchString(class) {
+		return class, nil
+	}
+	return "", &model.InvalidValueError{
+		Field:  kind,
+		Value:  class,
+		Reason: "unknown size class, eg: a class of sizes.yaml or a quantity such as 250m, 512Mi",
+	}
+}
+
+func copyMap(items map[string]string) map[string]string {
+	copied := make(map[string]string, len(items))
+	for k, v := range items {
+		copied[k] = v
+	}
+	return copied
+}
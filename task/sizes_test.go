@@ -0,0 +1,51 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	"github.com/kube-sailmaker/template-gen/source"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestLoadSizes(t *testing.T) {
+	provider := source.Dir("../sample-manifest/provider")
+	sizes, err := LoadSizesFrom(provider, "test")
+	test.Null(t, err)
+	quantity, err := sizes.ResolveCpu("c3")
+	test.Null(t, err)
+	test.EqualTo(t, "1", quantity)
+	quantity, err = sizes.ResolveCpu("c0")
+	test.Null(t, err)
+	test.EqualTo(t, "250m", quantity)
+	quantity, err = sizes.ResolveMemory("")
+	test.Null(t, err)
+	test.EqualTo(t, "256Mi", quantity)
+
+	sizes, err = LoadSizesFrom(provider, "prod")
+	test.Null(t, err)
+	quantity, err = sizes.ResolveCpu("c3")
+	test.Null(t, err)
+	test.EqualTo(t, "3", quantity)
+}
+
+func TestLoadSizesFallsBackToDefaults(t *testing.T) {
+	sizes, err := LoadSizesFrom(source.Dir("../sample-manifest/missing"), "test")
+	test.Null(t, err)
+	quantity, err := sizes.ResolveMemory("m2")
+	test.Null(t, err)
+	test.EqualTo(t, "2Gi", quantity)
+}
+
+func TestResolveSize(t *testing.T) {
+	sizes := DefaultSizes()
+	quantity, err := sizes.ResolveMemory("512Mi")
+	test.Null(t, err)
+	test.EqualTo(t, "512Mi", quantity)
+	_, err = sizes.ResolveCpu("c0")
+	test.NotNull(t, err)
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "cpu", invalid.Field)
+	test.EqualTo(t, "c0", invalid.Value)
+}
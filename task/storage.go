@@ -0,0 +1,134 @@
+package task
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Size of a volume claim when neither the claim nor the app template of the environment sets one
+const defaultStorageSize = "1Gi"
+
+var accessModes = []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany", "ReadWriteOncePod"}
+
+//Claim names are part of the names of the volumes and claims of the pods, eg: data
+var claimNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+//Function to set the volume claims of a StatefulSet. The storage of the app template of the environment
+//overrides the size and storage class of a claim, a claim without a size requests 1Gi
+func GenerateStorage(application *model.Application, environment string, appValues *templates.Application) error {
+	if len(application.VolumeClaims) == 0 {
+		return nil
+	}
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	if appValues.Kind != "StatefulSet" {
+		warn(appValues, location, "volumeClaimTemplates are ignored by kind %s, eg: kind: StatefulSet", workloadKind(appValues))
+		return nil
+	}
+	storage := make(map[string]model.Storage)
+	origin := make(map[string]string)
+	if environment != "" && len(application.Template) > 0 {
+		tmpl, err := ResolveTemplate(application, environment)
+		if err != nil {
+			return err
+		}
+		if tmpl.Storage != nil {
+			storage = tmpl.Storage
+			origin = tmpl.StorageOrigin
+		}
+	}
+
+	names := make(map[string]bool, len(application.VolumeClaims))
+	claims := make([]templates.VolumeClaim, 0, len(application.VolumeClaims))
+	for _, claim := range application.VolumeClaims {
+		if !claimNamePattern.MatchString(claim.Name) || names[claim.Name] {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "name",
+				Value:    claim.Name,
+				Reason:   "claim names are unique, lower case and up to 63 characters, eg: data",
+			}
+		}
+		names[claim.Name] = true
+		if !strings.HasPrefix(claim.MountPath, "/") {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "mountPath",
+				Value:    claim.MountPath,
+				Reason:   "an absolute path, eg: /var/lib/data",
+			}
+		}
+		modes := claim.AccessModes
+		if len(modes) == 0 {
+			modes = []string{"ReadWriteOnce"}
+		}
+		for _, mode := range modes {
+			if !contains(accessModes, mode) {
+				return &model.InvalidValueError{
+					Location: location,
+					Field:    "accessModes",
+					Value:    mode,
+					Reason:   fmt.Sprintf("eg: %s", strings.Join(accessModes, ", ")),
+				}
+			}
+		}
+
+		size, storageClass := claim.Size, claim.StorageClass
+		if s := storage[claim.Name]; s.Size != "" {
+			size = s.Size
+			setSource(appValues, "storage/"+claim.Name, size, templateSource+origin["storage/"+claim.Name])
+		}
+		if s := storage[claim.Name]; s.StorageClass != "" {
+			storageClass = s.StorageClass
+			setSource(appValues, "storageClass/"+claim.Name, storageClass, templateSource+origin["storageClass/"+claim.Name])
+		}
+		if size == "" {
+			size = defaultStorageSize
+			setSource(appValues, "storage/"+claim.Name, size, "default")
+		}
+		if match := quantityPattern.FindStringSubmatch(size); match == nil || match[2] == "m" {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "size",
+				Value:    size,
+				Reason:   "eg: 500Mi, 10Gi",
+			}
+		}
+		claims = append(claims, templates.VolumeClaim{
+			Name:         claim.Name,
+			MountPath:    claim.MountPath,
+			AccessModes:  modes,
+			StorageClass: storageClass,
+			Size:         size,
+		})
+	}
+
+	undefined := make([]string, 0)
+	for name := range storage {
+		if !names[name] {
+			undefined = append(undefined, name)
+		}
+	}
+	if len(undefined) > 0 {
+		sort.Strings(undefined)
+		return &model.InvalidReferenceError{
+			Location:  location,
+			Reference: undefined[0],
+			Reason:    "the storage of an app template is set for an undefined volume claim",
+		}
+	}
+	appValues.VolumeClaims = claims
+	return nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
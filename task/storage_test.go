@@ -0,0 +1,69 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestGenerateStorage(t *testing.T) {
+	application := &model.Application{
+		Name: "store",
+		Kind: "StatefulSet",
+		VolumeClaims: []model.VolumeClaim{
+			{Name: "data", MountPath: "/data", Size: "5Gi"},
+			{Name: "wal", MountPath: "/wal", AccessModes: []string{"ReadWriteOncePod"}},
+		},
+		Template: []model.AppTemplate{
+			{Name: "default", Storage: map[string]model.Storage{"data": {StorageClass: "standard"}}},
+			{Name: "prod", Storage: map[string]model.Storage{"data": {Size: "50Gi"}}},
+		},
+	}
+
+	appValues := &templates.Application{Name: "store", Kind: "StatefulSet"}
+	err := GenerateStorage(application, "prod", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 2, len(appValues.VolumeClaims))
+	test.EqualTo(t, "50Gi", appValues.VolumeClaims[0].Size)
+	test.EqualTo(t, "standard", appValues.VolumeClaims[0].StorageClass)
+	test.EqualTo(t, "ReadWriteOnce", appValues.VolumeClaims[0].AccessModes[0])
+	test.EqualTo(t, defaultStorageSize, appValues.VolumeClaims[1].Size)
+	test.EqualTo(t, model.ValueSource{Field: "storage/data", Value: "50Gi", Source: "template/prod"}, appValues.Sources[0])
+	test.EqualTo(t, model.ValueSource{Field: "storageClass/data", Value: "standard", Source: "template/default"}, appValues.Sources[1])
+
+	appValues = &templates.Application{Name: "store", Kind: "StatefulSet"}
+	err = GenerateStorage(application, "", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "5Gi", appValues.VolumeClaims[0].Size)
+	test.EqualTo(t, "", appValues.VolumeClaims[0].StorageClass)
+
+	application.Template[1].Storage["logs"] = model.Storage{Size: "1Gi"}
+	err = GenerateStorage(application, "prod", &templates.Application{Kind: "StatefulSet"})
+	var reference *model.InvalidReferenceError
+	test.EqualTo(t, true, errors.As(err, &reference))
+	test.EqualTo(t, "logs", reference.Reference)
+}
+
+func TestGenerateStorageValidatesClaims(t *testing.T) {
+	for field, claim := range map[string]model.VolumeClaim{
+		"name":        {Name: "Data", MountPath: "/data"},
+		"mountPath":   {Name: "data", MountPath: "data"},
+		"accessModes": {Name: "data", MountPath: "/data", AccessModes: []string{"ReadWrite"}},
+		"size":        {Name: "data", MountPath: "/data", Size: "10G b"},
+	} {
+		application := &model.Application{Name: "store", VolumeClaims: []model.VolumeClaim{claim}}
+		err := GenerateStorage(application, "", &templates.Application{Kind: "StatefulSet"})
+		var invalid *model.InvalidValueError
+		test.EqualTo(t, true, errors.As(err, &invalid))
+		test.EqualTo(t, field, invalid.Field)
+	}
+
+	appValues := &templates.Application{Name: "web"}
+	application := &model.Application{Name: "web", VolumeClaims: []model.VolumeClaim{{Name: "data", MountPath: "/data"}}}
+	err := GenerateStorage(application, "", appValues)
+	test.Null(t, err)
+	test.EqualTo(t, 0, len(appValues.VolumeClaims))
+	test.EqualTo(t, 1, len(appValues.Warnings))
+}
@@ -0,0 +1,186 @@
+package task
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//Descriptors a schedule can be written as instead of its five fields
+var scheduleDescriptors = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+var scheduleFieldPattern = regexp.MustCompile(`^[0-9A-Za-z*?/,-]+$`)
+
+//Function to set the job settings of a Job or CronJob, the schedule of a CronJob and the pod management and
+//update strategy of a StatefulSet. Pods of a job are not restarted always, and a schedule is five cron fields
+//or a descriptor with the time zone set on its own
+func GenerateWorkload(application *model.Application, appValues *templates.Application) error {
+	location := model.Location{App: application.Name, Kind: model.KindApplication}
+	kind := workloadKind(appValues)
+	if kind != "CronJob" && application.Schedule != "" {
+		warn(appValues, location, "schedule is ignored by kind %s, eg: kind: CronJob", kind)
+	}
+	if kind != "StatefulSet" && (application.PodManagementPolicy != "" || application.UpdateStrategy != nil) {
+		warn(appValues, location, "podManagementPolicy and updateStrategy are ignored by kind %s, eg: kind: StatefulSet", kind)
+	}
+	if kind == "StatefulSet" {
+		return generateStatefulSet(location, application, appValues)
+	}
+	if kind != "Job" && kind != "CronJob" {
+		return nil
+	}
+	if application.RestartPolicy == "Always" {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "restartPolicy",
+			Value:    application.RestartPolicy,
+			Reason:   fmt.Sprintf("pods of a %s restart OnFailure or Never", kind),
+		}
+	}
+	appValues.BackoffLimit = application.BackoffLimit
+	appValues.ActiveDeadLine = application.ActiveDeadLine
+	appValues.TTLSecondsAfterFinished = application.TTLSecondsAfterFinished
+	appValues.RestartPolicy = application.RestartPolicy
+	if kind != "CronJob" {
+		return nil
+	}
+
+	err := validateSchedule(location, application.Schedule)
+	if err != nil {
+		return err
+	}
+	if application.TimeZone != "" {
+		if _, err := time.LoadLocation(application.TimeZone); err != nil || application.TimeZone == "Local" {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    "timeZone",
+				Value:    application.TimeZone,
+				Reason:   "eg: Etc/UTC, Europe/London",
+			}
+		}
+	}
+	switch application.ConcurrencyPolicy {
+	case "", "Allow", "Forbid", "Replace":
+	default:
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "concurrencyPolicy",
+			Value:    application.ConcurrencyPolicy,
+			Reason:   "eg: Allow, Forbid, Replace",
+		}
+	}
+	for _, limit := range []struct {
+		field string
+		value *int
+	}{
+		{"startingDeadlineSeconds", &application.StartingDeadline},
+		{"successfulJobsHistoryLimit", application.SuccessfulJobsHistory},
+		{"failedJobsHistoryLimit", application.FailedJobsHistory},
+	} {
+		if limit.value != nil && *limit.value < 0 {
+			return &model.InvalidValueError{
+				Location: location,
+				Field:    limit.field,
+				Value:    fmt.Sprint(*limit.value),
+				Reason:   "cannot be negative",
+			}
+		}
+	}
+	appValues.Schedule = application.Schedule
+	appValues.TimeZone = application.TimeZone
+	appValues.ConcurrencyPolicy = application.ConcurrencyPolicy
+	appValues.StartingDeadline = application.StartingDeadline
+	appValues.SuccessfulJobsHistory = application.SuccessfulJobsHistory
+	appValues.FailedJobsHistory = application.FailedJobsHistory
+	return nil
+}
+
+//generateStatefulSet sets the pod management policy and update strategy, a partition only applies to rolling updates
+func generateStatefulSet(location model.Location, application *model.Application, appValues *templates.Application) error {
+	switch application.PodManagementPolicy {
+	case "", "OrderedReady", "Parallel":
+	default:
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "podManagementPolicy",
+			Value:    application.PodManagementPolicy,
+			Reason:   "eg: OrderedReady, Parallel",
+		}
+	}
+	appValues.PodManagementPolicy = application.PodManagementPolicy
+	strategy := application.UpdateStrategy
+	if strategy == nil {
+		return nil
+	}
+	switch strategy.Type {
+	case "", "RollingUpdate", "OnDelete":
+	default:
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "type",
+			Value:    strategy.Type,
+			Reason:   "eg: RollingUpdate, OnDelete",
+		}
+	}
+	if strategy.Partition < 0 || (strategy.Partition > 0 && strategy.Type == "OnDelete") {
+		return &model.InvalidValueError{
+			Location: location,
+			Field:    "partition",
+			Value:    fmt.Sprint(strategy.Partition),
+			Reason:   "a positive ordinal of a RollingUpdate",
+		}
+	}
+	appValues.UpdateStrategy = strategy.Type
+	if appValues.UpdateStrategy == "" {
+		appValues.UpdateStrategy = "RollingUpdate"
+	}
+	appValues.Partition = strategy.Partition
+	return nil
+}
+
+//workloadKind is the kind of the workload of the app, an empty kind is a Deployment
+func workloadKind(appValues *templates.Application) string {
+	if appValues.Kind == "" {
+		return "Deployment"
+	}
+	return appValues.Kind
+}
+
+//validateSchedule checks a CronJob schedule is a descriptor or five cron fields
+func validateSchedule(location model.Location, schedule string) error {
+	invalid := &model.InvalidValueError{
+		Location: location,
+		Field:    "schedule",
+		Value:    schedule,
+		Reason:   `eg: "30 2 * * *", @daily`,
+	}
+	if schedule == "" {
+		invalid.Reason = `a CronJob requires a schedule, eg: "30 2 * * *", @daily`
+		return invalid
+	}
+	if strings.Contains(schedule, "TZ=") {
+		invalid.Reason = "set the time zone with timeZone"
+		return invalid
+	}
+	if strings.HasPrefix(schedule, "@") {
+		for _, descriptor := range scheduleDescriptors {
+			if schedule == descriptor {
+				return nil
+			}
+		}
+		return invalid
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return invalid
+	}
+	for _, field := range fields {
+		if !scheduleFieldPattern.MatchString(field) {
+			return invalid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package task
+
+import (
+	"errors"
+	"github.com/kube-sailmaker/template-gen/model"
+	templates "github.com/kube-sailmaker/template-gen/template"
+	"github.com/kube-sailmaker/template-gen/test"
+	"testing"
+)
+
+func TestGenerateWorkloadCronJob(t *testing.T) {
+	history := 0
+	application := &model.Application{
+		Name:                  "report",
+		Kind:                  "CronJob",
+		Schedule:              "*/15 6-18 * * 1-5",
+		TimeZone:              "Etc/UTC",
+		ConcurrencyPolicy:     "Forbid",
+		SuccessfulJobsHistory: &history,
+		BackoffLimit:          3,
+		RestartPolicy:         "OnFailure",
+	}
+	appValues := &templates.Application{Name: "report", Kind: "CronJob"}
+	err := GenerateWorkload(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "*/15 6-18 * * 1-5", appValues.Schedule)
+	test.EqualTo(t, "Forbid", appValues.ConcurrencyPolicy)
+	test.EqualTo(t, 0, *appValues.SuccessfulJobsHistory)
+	test.EqualTo(t, 3, appValues.BackoffLimit)
+	test.EqualTo(t, "OnFailure", appValues.RestartPolicy)
+
+	for field, change := range map[string]func(*model.Application){
+		"schedule":                func(a *model.Application) { a.Schedule = "" },
+		"timeZone":                func(a *model.Application) { a.TimeZone = "Mars/Olympus" },
+		"restartPolicy":           func(a *model.Application) { a.RestartPolicy = "Always" },
+		"startingDeadlineSeconds": func(a *model.Application) { a.StartingDeadline = -1 },
+	} {
+		invalidApplication := *application
+		change(&invalidApplication)
+		err = GenerateWorkload(&invalidApplication, &templates.Application{Kind: "CronJob"})
+		var invalid *model.InvalidValueError
+		test.EqualTo(t, true, errors.As(err, &invalid))
+		test.EqualTo(t, field, invalid.Field)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for schedule, valid := range map[string]bool{
+		"0 * * * *":           true,
+		"@daily":              true,
+		"0 0 1 JAN MON":       true,
+		"@every-day":          false,
+		"0 * * *":             false,
+		"TZ=UTC 0 * * * *":    false,
+		"0 * * * * *":         false,
+		"0 {{ .Hour }} * * *": false,
+	} {
+		err := validateSchedule(model.Location{App: "report"}, schedule)
+		test.EqualTo(t, valid, err == nil)
+	}
+}
+
+func TestGenerateWorkloadIgnoresScheduleOfJob(t *testing.T) {
+	application := &model.Application{Name: "batch", Kind: "Job", Schedule: "@daily", TTLSecondsAfterFinished: 30}
+	appValues := &templates.Application{Name: "batch", Kind: "Job"}
+	err := GenerateWorkload(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "", appValues.Schedule)
+	test.EqualTo(t, 30, appValues.TTLSecondsAfterFinished)
+	test.EqualTo(t, 1, len(appValues.Warnings))
+}
+
+func TestGenerateWorkloadStatefulSet(t *testing.T) {
+	application := &model.Application{
+		Name:                "store",
+		Kind:                "StatefulSet",
+		PodManagementPolicy: "Parallel",
+		UpdateStrategy:      &model.UpdateStrategy{Partition: 2},
+	}
+	appValues := &templates.Application{Name: "store", Kind: "StatefulSet"}
+	err := GenerateWorkload(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "Parallel", appValues.PodManagementPolicy)
+	test.EqualTo(t, "RollingUpdate", appValues.UpdateStrategy)
+	test.EqualTo(t, 2, appValues.Partition)
+
+	application.UpdateStrategy.Type = "OnDelete"
+	err = GenerateWorkload(application, &templates.Application{Kind: "StatefulSet"})
+	var invalid *model.InvalidValueError
+	test.EqualTo(t, true, errors.As(err, &invalid))
+	test.EqualTo(t, "partition", invalid.Field)
+
+	appValues = &templates.Application{Name: "store"}
+	err = GenerateWorkload(application, appValues)
+	test.Null(t, err)
+	test.EqualTo(t, "", appValues.UpdateStrategy)
+	test.EqualTo(t, 1, len(appValues.Warnings))
+}
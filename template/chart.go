@@ -0,0 +1,185 @@
+package templates
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const defaultChartVersion = "0.1.0"
+
+var semverPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+//RunChart writes the release as a helm chart: Chart.yaml, a values.yaml holding the resolved values
+//of every app and the app templates reading those values through helm placeholders
+func RunChart(releaseTemplate *ReleaseTemplate, outputDir string) (*model.DeploymentItemSummary, error) {
+	release := *releaseTemplate
+	if release.ChartVersion == "" {
+		release.ChartVersion = defaultChartVersion
+	}
+	if !semverPattern.MatchString(release.ChartVersion) {
+		return nil, fmt.Errorf("chart version %s is not a semantic version, eg: 1.0.0", release.ChartVersion)
+	}
+	chartDir := fmt.Sprintf("%s/%s/", outputDir, strings.ToLower(release.ReleaseName))
+	templateDir := chartDir + "templates/"
+	cerr := createDirSafely(templateDir)
+	if cerr != nil {
+		return nil, cerr
+	}
+
+	chart, err := LoadTemplates("ChartTemplate", nil)
+	if err != nil {
+		return nil, err
+	}
+	err = writeTemplate(chart, &release, chartDir+"Chart.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.DeploymentItem, 0)
+	appValues := make(map[string]interface{}, 0)
+	for _, application := range release.Application {
+		log.Println("Generating chart template for: ", application.Name)
+		appValues[application.Name] = chartValues(&application)
+		kind, _, err := writeTemplates(&application, helmPlaceholders(&application), helmFuncs, templateDir)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, model.DeploymentItem{
+			Name:    application.Name,
+			Kind:    kind,
+			Path:    templateDir,
+			Sources: application.Sources,
+		})
+	}
+
+	values, err := yaml.Marshal(map[string]interface{}{"apps": appValues})
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(chartDir+"values.yaml", values, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &model.DeploymentItemSummary{
+		Namespace: release.Namespace,
+		Items:     items,
+	}, nil
+}
+
+//chartValues holds the values of an application that differ between releases and environments
+func chartValues(application *Application) map[string]interface{} {
+	values := map[string]interface{}{
+		"tag":      application.Tag,
+		"replicas": application.Replicas,
+		"resources": map[string]interface{}{
+			"limits":   application.Limits,
+			"requests": application.Requests,
+		},
+		"env":     application.EnvVars,
+		"secrets": secretNames(application.SecretEnvVars),
+		"config":  application.ConfigEnvVars,
+	}
+	if replicas, err := strconv.Atoi(application.Replicas); err == nil {
+		values["replicas"] = replicas
+	}
+	if application.ImageDigest != "" {
+		values["digest"] = application.ImageDigest
+	}
+	if len(application.VolumeClaims) > 0 {
+		storage := make(map[string]interface{}, len(application.VolumeClaims))
+		for _, claim := range application.VolumeClaims {
+			claimValues := map[string]string{"size": claim.Size}
+			if claim.StorageClass != "" {
+				claimValues["storageClass"] = claim.StorageClass
+			}
+			storage[claim.Name] = claimValues
+		}
+		values["storage"] = storage
+	}
+	return values
+}
+
+//helmPlaceholders replaces the values of chartValues with helm expressions reading them from values.yaml
+func helmPlaceholders(application *Application) *Application {
+	ref := fmt.Sprintf("(index .Values.apps %q)", application.Name)
+	placeholders := *application
+	placeholders.Namespace = "{{ .Release.Namespace }}"
+	placeholders.Tag = fmt.Sprintf("{{ %s.tag }}", ref)
+	if application.ImageDigest != "" {
+		placeholders.ImageDigest = fmt.Sprintf("{{ %s.digest }}", ref)
+	}
+	placeholders.Replicas = fmt.Sprintf("{{ %s.replicas }}", ref)
+	placeholders.VolumeClaims = make([]VolumeClaim, len(application.VolumeClaims))
+	for i, claim := range application.VolumeClaims {
+		claim.Size = fmt.Sprintf("{{ index %s.storage %q \"size\" }}", ref, claim.Name)
+		if claim.StorageClass != "" {
+			claim.StorageClass = fmt.Sprintf("{{ index %s.storage %q \"storageClass\" }}", ref, claim.Name)
+		}
+		placeholders.VolumeClaims[i] = claim
+	}
+	placeholders.Limits = placeholderMap(application.Limits, ref+".resources.limits")
+	placeholders.Requests = placeholderMap(application.Requests, ref+".resources.requests")
+	placeholders.EnvVars = make(map[string]string, len(application.EnvVars))
+	for k := range application.EnvVars {
+		placeholders.EnvVars[k] = fmt.Sprintf("{{ index %s.env %q }}", ref, k)
+	}
+	placeholders.SecretEnvVars = make(map[string]string, len(application.SecretEnvVars))
+	for k := range application.SecretEnvVars {
+		value := fmt.Sprintf("apps.%s.secrets.%s", application.Name, k)
+		required := fmt.Sprintf("%s is required, eg: --set-string %s=<value>", value, value)
+		placeholders.SecretEnvVars[k] = fmt.Sprintf("{{ required %q (index %s.secrets %q) }}", required, ref, k)
+	}
+	placeholders.ConfigEnvVars = make(map[string]string, len(application.ConfigEnvVars))
+	for k := range application.ConfigEnvVars {
+		placeholders.ConfigEnvVars[k] = fmt.Sprintf("{{ index %s.config %q }}", ref, k)
+	}
+	if _, ok := application.PodAnnotations[ConfigHashAnnotation]; ok {
+		//the hash follows the values.yaml the chart is installed with
+		placeholders.PodAnnotations = make(map[string]string, len(application.PodAnnotations))
+		for k, v := range application.PodAnnotations {
+			placeholders.PodAnnotations[k] = v
+		}
+		placeholders.PodAnnotations[ConfigHashAnnotation] = fmt.Sprintf(`{{ include (print $.Template.BasePath "/%s-configmap.yaml") . | sha256sum }}`, application.Name)
+	}
+	return &placeholders
+}
+
+//secretNames lists the secrets of an app without their values, which are set when the chart is installed
+//instead of being kept in plain text in values.yaml
+func secretNames(secrets map[string]string) map[string]string {
+	names := make(map[string]string, len(secrets))
+	for k := range secrets {
+		names[k] = ""
+	}
+	return names
+}
+
+//helmFuncs render the templates of a chart, the helm placeholders are quoted by helm once their value is known
+var helmFuncs = template.FuncMap{
+	"Quote": helmQuote,
+}
+
+//helmQuote quotes a helm placeholder with the quote function of helm, eg: {{ index .Values.env "X" | quote }},
+//any other value as a yaml string
+func helmQuote(value interface{}) string {
+	s := fmt.Sprint(value)
+	if strings.HasPrefix(s, "{{ ") && strings.HasSuffix(s, " }}") && !strings.Contains(s[3:len(s)-3], "}}") {
+		return strings.TrimSuffix(s, " }}") + " | quote }}"
+	}
+	return quote(s)
+}
+
+func placeholderMap(items map[string]string, path string) map[string]string {
+	placeholders := make(map[string]string, len(items))
+	for k := range items {
+		placeholders[k] = fmt.Sprintf("{{ %s.%s }}", path, k)
+	}
+	return placeholders
+}
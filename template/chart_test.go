@@ -0,0 +1,54 @@
+package templates
+
+import (
+	"github.com/kube-sailmaker/template-gen/test"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunChart(t *testing.T) {
+	outputDir := "../tmp-chart"
+	defer os.RemoveAll(outputDir)
+	release := ReleaseTemplate{
+		Namespace:   "apps",
+		ReleaseName: "Release-2",
+		Application: []Application{{
+			Name:          "busybox",
+			ReleaseName:   "Release-2",
+			Tag:           "1.0",
+			Replicas:      "2",
+			Limits:        map[string]string{"cpu": "1", "memory": "1Gi"},
+			Requests:      map[string]string{"cpu": "500m", "memory": "512Mi"},
+			EnvVars:       map[string]string{"LOG_LEVEL": "DEBUG"},
+			SecretEnvVars: map[string]string{"DB_PASSWORD": "s3cret"},
+		}},
+	}
+	summary, err := RunChart(&release, outputDir)
+	test.Null(t, err)
+	test.EqualTo(t, 1, len(summary.Items))
+
+	chart, err := ioutil.ReadFile(outputDir + "/release-2/Chart.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(chart), "version: 0.1.0"))
+	values, err := ioutil.ReadFile(outputDir + "/release-2/values.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(values), "replicas: 2"))
+	test.EqualTo(t, false, strings.Contains(string(values), "s3cret"))
+	deployment, err := ioutil.ReadFile(outputDir + "/release-2/templates/busybox-deployment.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(deployment), `replicas: {{ (index .Values.apps "busybox").replicas }}`))
+	test.EqualTo(t, true, strings.Contains(string(deployment), `version: {{ (index .Values.apps "busybox").tag | quote }}`))
+	test.EqualTo(t, true, strings.Contains(string(deployment), `value: {{ index (index .Values.apps "busybox").env "LOG_LEVEL" | quote }}`))
+	secret, err := ioutil.ReadFile(outputDir + "/release-2/templates/busybox-secret.yaml")
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(string(secret), `"DB_PASSWORD": {{ required "apps.busybox.secrets.DB_PASSWORD is required, eg: --set-string apps.busybox.secrets.DB_PASSWORD=<value>" (index (index .Values.apps "busybox").secrets "DB_PASSWORD") | quote }}`))
+}
+
+func TestRunChartRejectsInvalidVersion(t *testing.T) {
+	release := ReleaseTemplate{ReleaseName: "apps", ChartVersion: "1.0"}
+	_, err := RunChart(&release, "../tmp-chart")
+	test.NotNull(t, err)
+	test.EqualTo(t, "chart version 1.0 is not a semantic version, eg: 1.0.0", err.Error())
+}
@@ -1,13 +1,13 @@
 package templates
 
 import (
-	"errors"
 	"fmt"
 	"github.com/kube-sailmaker/template-gen/model"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 )
 
 func createDirSafely(fileName string) error {
@@ -33,26 +33,15 @@ func Run(releaseTemplate *ReleaseTemplate, outputDir string) (*model.DeploymentI
 		}
 		log.Println("Generating template for: ", application.Name)
 
-		requiredTemplates, kind := GetRequiredTemplates(&application)
-		for _, tName := range requiredTemplates {
-			tmpl, err := LoadTemplates(tName, &application)
-			if err != nil {
-				return nil, errors.New(fmt.Sprintf("[app]: %s, [error]: %v", application.Name, err))
-			}
-			file, er := os.Create(fmt.Sprintf("%s/%s", appWorkDir, tmpl.Name()))
-			if er != nil {
-				return nil, er
-			}
-
-			exErr := tmpl.Execute(file, &application)
-			if exErr != nil {
-				return nil, errors.New(fmt.Sprintf("[app]: %s, [error]: %v", application.Name, err))
-			}
+		kind, _, err := writeTemplates(&application, &application, nil, appWorkDir)
+		if err != nil {
+			return nil, err
 		}
 		items = append(items, model.DeploymentItem{
-			Name: application.Name,
-			Kind: kind,
-			Path: appWorkDir,
+			Name:    application.Name,
+			Kind:    kind,
+			Path:    appWorkDir,
+			Sources: application.Sources,
 		})
 	}
 	itemSummary = model.DeploymentItemSummary{
@@ -63,6 +52,47 @@ func Run(releaseTemplate *ReleaseTemplate, outputDir string) (*model.DeploymentI
 
 }
 
+//writeTemplates renders every template required by the application into dir, executing them with data.
+//Funcs, when set, replace the template functions, eg: the helm quoting of a chart.
+//It returns the kind of the workload and the names of the written files
+func writeTemplates(application *Application, data interface{}, funcs template.FuncMap, dir string) (string, []string, error) {
+	files := make([]string, 0)
+	requiredTemplates, kind := GetRequiredTemplates(application)
+	for _, tName := range requiredTemplates {
+		tmpl, err := LoadTemplates(tName, application)
+		if err != nil {
+			return "", nil, renderError(application, tName, err)
+		}
+		if funcs != nil {
+			tmpl.Funcs(funcs)
+		}
+		err = writeTemplate(tmpl, data, fmt.Sprintf("%s/%s", dir, tmpl.Name()))
+		if err != nil {
+			return "", nil, renderError(application, tName, err)
+		}
+		files = append(files, tmpl.Name())
+	}
+	return kind, files, nil
+}
+
+//renderError reports a template of the application that failed to parse, execute or write
+func renderError(application *Application, tName string, err error) error {
+	return &model.RenderError{
+		Location: model.Location{App: application.Name},
+		Template: tName,
+		Err:      err,
+	}
+}
+
+func writeTemplate(tmpl *template.Template, data interface{}, fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return tmpl.Execute(file, data)
+}
+
 func GetRequiredTemplates(application *Application) ([]string, string) {
 	kind := ""
 	requiredTemplates := make([]string, 0)
@@ -73,10 +103,28 @@ func GetRequiredTemplates(application *Application) ([]string, string) {
 	} else if strings.EqualFold(application.Kind, "Job") {
 		requiredTemplates = append(requiredTemplates, "JobTemplate")
 		kind = "job"
+	} else if strings.EqualFold(application.Kind, "CronJob") {
+		requiredTemplates = append(requiredTemplates, "CronJobTemplate")
+		kind = "cronjob"
+	} else if strings.EqualFold(application.Kind, "StatefulSet") {
+		requiredTemplates = append(requiredTemplates, "StatefulSetTemplate")
+		if !application.ServiceEnabled || !application.Headless {
+			requiredTemplates = append(requiredTemplates, "HeadlessServiceTemplate")
+		}
+		kind = "statefulset"
 	}
 
 	if application.ServiceEnabled {
 		requiredTemplates = append(requiredTemplates, "ServiceTemplate")
 	}
+	if len(application.Rules) > 0 {
+		requiredTemplates = append(requiredTemplates, "RoleTemplate")
+	}
+	if len(application.SecretEnvVars) > 0 {
+		requiredTemplates = append(requiredTemplates, "SecretTemplate")
+	}
+	if len(application.ConfigEnvVars) > 0 {
+		requiredTemplates = append(requiredTemplates, "ConfigMapTemplate")
+	}
 	return requiredTemplates, kind
 }
@@ -0,0 +1,156 @@
+package templates
+
+import (
+	"fmt"
+	"github.com/kube-sailmaker/template-gen/model"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+)
+
+const kustomizeApiVersion = "kustomize.config.k8s.io/v1beta1"
+
+type kustomization struct {
+	ApiVersion string           `yaml:"apiVersion"`
+	Kind       string           `yaml:"kind"`
+	Namespace  string           `yaml:"namespace,omitempty"`
+	Resources  []string         `yaml:"resources"`
+	Patches    []kustomizePatch `yaml:"patches,omitempty"`
+}
+
+type kustomizePatch struct {
+	Path string `yaml:"path"`
+}
+
+//RunKustomize writes the release as a kustomize base rendered from the environment agnostic values of base,
+//and an overlay per environment patching the replicas, resources, env vars, config and secrets of its apps
+func RunKustomize(base *ReleaseTemplate, overlays []ReleaseTemplate, outputDir string) (*model.DeploymentItemSummary, error) {
+	releaseDir := fmt.Sprintf("%s/%s/", outputDir, strings.ToLower(base.ReleaseName))
+	baseDir := releaseDir + "base/"
+
+	items := make([]model.DeploymentItem, 0)
+	resources := make([]string, 0)
+	for _, application := range base.Application {
+		appWorkDir := fmt.Sprintf("%s%s/", baseDir, application.Name)
+		cerr := createDirSafely(appWorkDir)
+		if cerr != nil {
+			return nil, cerr
+		}
+		log.Println("Generating kustomize base for: ", application.Name)
+		kind, files, err := writeTemplates(&application, &application, nil, appWorkDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			resources = append(resources, application.Name+"/"+file)
+		}
+		items = append(items, model.DeploymentItem{
+			Name:    application.Name,
+			Kind:    kind,
+			Path:    appWorkDir,
+			Sources: application.Sources,
+		})
+	}
+	err := writeKustomization(baseDir, &kustomization{
+		Namespace: base.Namespace,
+		Resources: resources,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, overlay := range overlays {
+		overlayDir := fmt.Sprintf("%soverlays/%s/", releaseDir, overlay.Environment)
+		cerr := createDirSafely(overlayDir)
+		if cerr != nil {
+			return nil, cerr
+		}
+		patches := make([]kustomizePatch, 0)
+		overlayResources := []string{"../../base"}
+		for _, application := range overlay.Application {
+			_, kind := GetRequiredTemplates(&application)
+			tName, ok := patchTemplates[kind]
+			if !ok {
+				continue
+			}
+			tmpl, err := LoadTemplates(tName, &application)
+			if err != nil {
+				return nil, renderError(&application, tName, err)
+			}
+			err = writeTemplate(tmpl, &application, overlayDir+tmpl.Name())
+			if err != nil {
+				return nil, renderError(&application, tName, err)
+			}
+			patches = append(patches, kustomizePatch{Path: tmpl.Name()})
+
+			//The ConfigMap and Secret of the environment patch those of the base, or are added when the base has none
+			baseApplication := baseApplicationOf(base, application.Name)
+			for _, object := range []struct {
+				tName string
+				items func(*Application) map[string]string
+			}{
+				{"ConfigMapTemplate", func(a *Application) map[string]string { return a.ConfigEnvVars }},
+				{"SecretTemplate", func(a *Application) map[string]string { return a.SecretEnvVars }},
+			} {
+				baseItems := object.items(baseApplication)
+				if len(object.items(&application)) == 0 || reflect.DeepEqual(baseItems, object.items(&application)) {
+					continue
+				}
+				tmpl, err := LoadTemplates(object.tName, &application)
+				if err != nil {
+					return nil, renderError(&application, object.tName, err)
+				}
+				err = writeTemplate(tmpl, &application, overlayDir+tmpl.Name())
+				if err != nil {
+					return nil, renderError(&application, object.tName, err)
+				}
+				if len(baseItems) > 0 {
+					patches = append(patches, kustomizePatch{Path: tmpl.Name()})
+				} else {
+					overlayResources = append(overlayResources, tmpl.Name())
+				}
+			}
+		}
+		err := writeKustomization(overlayDir, &kustomization{
+			Resources: overlayResources,
+			Patches:   patches,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &model.DeploymentItemSummary{
+		Namespace: base.Namespace,
+		Items:     items,
+	}, nil
+}
+
+//baseApplicationOf returns the values of an app in the base
+func baseApplicationOf(base *ReleaseTemplate, name string) *Application {
+	for i := range base.Application {
+		if base.Application[i].Name == name {
+			return &base.Application[i]
+		}
+	}
+	return &Application{}
+}
+
+//patchTemplates maps the kind of a workload to the template patching it in an overlay
+var patchTemplates = map[string]string{
+	"deployment":  "DeploymentPatchTemplate",
+	"job":         "JobPatchTemplate",
+	"cronjob":     "CronJobPatchTemplate",
+	"statefulset": "StatefulSetPatchTemplate",
+}
+
+func writeKustomization(dir string, k *kustomization) error {
+	k.ApiVersion = kustomizeApiVersion
+	k.Kind = "Kustomization"
+	content, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dir+"kustomization.yaml", content, 0644)
+}
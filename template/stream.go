@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+)
+
+//streamOrder is the order objects are written to a stream, dependencies of a workload come first
+var streamOrder = []string{
+	"ServiceAccountTemplate",
+	"RoleTemplate",
+	"SecretTemplate",
+	"ConfigMapTemplate",
+	"ServiceTemplate",
+	"HeadlessServiceTemplate",
+	"DeploymentTemplate",
+	"StatefulSetTemplate",
+	"JobTemplate",
+	"CronJobTemplate",
+}
+
+//Write renders every object of the release as one multi document yaml stream, grouped by kind in streamOrder
+//and by app in release order. With sourceComments each document starts with the app and file it belongs to
+func Write(releaseTemplate *ReleaseTemplate, w io.Writer, sourceComments bool) error {
+	required := make([]map[string]bool, len(releaseTemplate.Application))
+	for i := range releaseTemplate.Application {
+		templateNames, _ := GetRequiredTemplates(&releaseTemplate.Application[i])
+		required[i] = make(map[string]bool, len(templateNames))
+		for _, tName := range templateNames {
+			required[i][tName] = true
+		}
+	}
+
+	for _, tName := range streamOrder {
+		for i := range releaseTemplate.Application {
+			application := &releaseTemplate.Application[i]
+			if !required[i][tName] {
+				continue
+			}
+			tmpl, err := LoadTemplates(tName, application)
+			if err != nil {
+				return renderError(application, tName, err)
+			}
+			_, err = io.WriteString(w, "---\n")
+			if err != nil {
+				return err
+			}
+			if sourceComments {
+				_, err = fmt.Fprintf(w, "# Source: %s/%s\n", application.Name, tmpl.Name())
+				if err != nil {
+					return err
+				}
+			}
+			err = tmpl.Execute(w, application)
+			if err != nil {
+				return renderError(application, tName, err)
+			}
+		}
+	}
+	return nil
+}
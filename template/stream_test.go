@@ -0,0 +1,43 @@
+package templates
+
+import (
+	"bytes"
+	"github.com/kube-sailmaker/template-gen/test"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	release := ReleaseTemplate{
+		Namespace: "apps",
+		Application: []Application{
+			{Name: "nginx", ReleaseName: "web", ServiceEnabled: true, ServiceType: "ClusterIP", ProbePort: "http", Ports: []Port{{Name: "http", Protocol: "TCP", Port: 80, ContainerPort: 80}}},
+			{Name: "eod-job", ReleaseName: "web", Kind: "Job", SecretEnvVars: map[string]string{"DB_PASSWORD": "secret"}},
+		},
+	}
+	out := &bytes.Buffer{}
+	err := Write(&release, out, true)
+	test.Null(t, err)
+
+	sources := make([]string, 0)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "# Source: ") {
+			sources = append(sources, strings.TrimPrefix(line, "# Source: "))
+		}
+	}
+	expected := []string{
+		"nginx/nginx-serviceaccount.yaml",
+		"eod-job/eod-job-serviceaccount.yaml",
+		"eod-job/eod-job-secret.yaml",
+		"nginx/nginx-service.yaml",
+		"nginx/nginx-deployment.yaml",
+		"eod-job/eod-job-job.yaml",
+	}
+	test.EqualTo(t, len(expected), len(sources))
+	for i := range expected {
+		test.EqualTo(t, expected[i], sources[i])
+	}
+	test.EqualTo(t, 6, strings.Count(out.String(), "---\n"))
+	test.EqualTo(t, 1, strings.Count(out.String(), "secret\""))
+	test.EqualTo(t, true, strings.Contains(out.String(), "secretKeyRef:\n                name: web-eod-job\n                key: \"DB_PASSWORD\""))
+}
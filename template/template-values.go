@@ -1,9 +1,16 @@
 package templates
 
+import (
+	"github.com/kube-sailmaker/template-gen/model"
+	"strings"
+)
+
 type ReleaseTemplate struct {
-	Namespace   string
-	Environment string
-	Application []Application
+	Namespace    string
+	ReleaseName  string
+	Environment  string
+	ChartVersion string
+	Application  []Application
 }
 
 type Application struct {
@@ -12,19 +19,113 @@ type Application struct {
 	Namespace               string
 	Name                    string
 	Tag                     string
+	ImageRepository         string
+	ImageDigest             string
+	ImagePullPolicy         string
+	ImagePullSecrets        []string
 	Annotations             map[string]string
+	PodAnnotations          map[string]string
 	Replicas                string
-	LivenessProbe           string
-	ReadinessProbe          string
+	LivenessProbe           *model.Probe
+	ReadinessProbe          *model.Probe
+	StartupProbe            *model.Probe
 	EnvVars                 map[string]string
+	SecretEnvVars           map[string]string `json:"-"`
+	ConfigEnvVars           map[string]string
 	Limits                  map[string]string
+	Requests                map[string]string
 	Command                 []string
 	Entrypoint              []string
 	ContainerPort           int
+	Ports                   []Port
+	ProbePort               string
 	ServiceEnabled          bool
+	ServiceType             string
+	ServiceAnnotations      map[string]string
+	Headless                bool
 	Parallelism             int
 	BackoffLimit            int
 	ActiveDeadLine          int
 	TTLSecondsAfterFinished int
 	RestartPolicy           string
+	Schedule                string
+	TimeZone                string
+	ConcurrencyPolicy       string
+	StartingDeadline        int
+	SuccessfulJobsHistory   *int
+	FailedJobsHistory       *int
+	PodManagementPolicy     string
+	UpdateStrategy          string
+	Partition               int
+	VolumeClaims            []VolumeClaim
+	Placement               *model.Placement
+	Volumes                 []Volume
+	Sidecars                []Sidecar
+	Rules                   []PolicyRule
+	Sources                 []model.ValueSource
+	Warnings                model.Diagnostics
+}
+
+//ImageRef is the image reference of the app container, eg: registry.local/team/api:1.0@sha256:...
+func (a *Application) ImageRef() string {
+	ref := a.ImageRepository
+	if ref == "" {
+		ref = a.Name
+	}
+	if a.Tag != "" {
+		ref += ":" + a.Tag
+	}
+	if a.ImageDigest != "" {
+		ref += "@" + a.ImageDigest
+	}
+	return ref
+}
+
+//GoverningService names the headless service of a StatefulSet, the service of the app when it is headless
+func (a *Application) GoverningService() string {
+	name := strings.ToLower(a.ReleaseName) + "-" + strings.ToLower(a.Name)
+	if a.ServiceEnabled && a.Headless {
+		return name
+	}
+	return name + "-headless"
+}
+
+type Port struct {
+	Name          string
+	Protocol      string
+	Port          int
+	ContainerPort int
+	AppProtocol   string
+	NodePort      int
+}
+
+type VolumeClaim struct {
+	Name         string
+	MountPath    string
+	AccessModes  []string
+	StorageClass string
+	Size         string
+}
+
+type Volume struct {
+	Name      string
+	MountPath string
+	ReadOnly  bool
+	Secret    string
+	ConfigMap string
+}
+
+type Sidecar struct {
+	Name         string
+	Image        string
+	Args         []string
+	Env          map[string]string
+	Port         int
+	VolumeMounts []Volume
+}
+
+type PolicyRule struct {
+	ApiGroups []string
+	Resources []string
+	Verbs     []string
 }
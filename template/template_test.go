@@ -1,7 +1,12 @@
 package templates
 
 import (
+	"bytes"
+	"github.com/kube-sailmaker/template-gen/model"
 	"github.com/kube-sailmaker/template-gen/test"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -14,8 +19,8 @@ func TestLoadTemplates(t *testing.T) {
 		Tag:            "latest",
 		Annotations:    nil,
 		Replicas:       "1",
-		LivenessProbe:  "/health",
-		ReadinessProbe: "/ready",
+		LivenessProbe:  &model.Probe{HttpGet: &model.HttpGetAction{Path: "/health", Port: "http"}, PeriodSeconds: 10},
+		ReadinessProbe: &model.Probe{Exec: &model.ExecAction{Command: []string{"cat", "/tmp/ready"}}},
 		EnvVars:        nil,
 		Limits:         nil,
 		Command:        cmd,
@@ -26,4 +31,345 @@ func TestLoadTemplates(t *testing.T) {
 	test.Null(t, err)
 	test.NotNull(t, template)
 	test.EqualTo(t, "busybox-deployment.yaml", template.Name())
+
+	out := &bytes.Buffer{}
+	err = template.Execute(out, &application)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), `livenessProbe:
+           httpGet:
+             path: /health
+             port: http
+           periodSeconds: 10
+`))
+	test.EqualTo(t, true, strings.Contains(out.String(), `readinessProbe:
+           exec:
+             command:
+             - "cat"
+             - "/tmp/ready"
+`))
+}
+
+func TestGetRequiredTemplatesWithRules(t *testing.T) {
+	application := Application{
+		Name:  "busybox",
+		Rules: []PolicyRule{{Resources: []string{"pods"}, Verbs: []string{"get"}}},
+	}
+	required, kind := GetRequiredTemplates(&application)
+	test.EqualTo(t, "deployment", kind)
+	test.EqualTo(t, "RoleTemplate", required[len(required)-1])
+}
+
+func TestGetRequiredTemplatesCronJob(t *testing.T) {
+	history := 0
+	application := Application{
+		ReleaseName:       "apps",
+		Name:              "report",
+		Kind:              "CronJob",
+		Tag:               "1.0",
+		Schedule:          "@daily",
+		ConcurrencyPolicy: "Forbid",
+		FailedJobsHistory: &history,
+		Placement:         &model.Placement{NodeSelector: map[string]string{"kubernetes.io/os": "linux"}},
+	}
+	required, kind := GetRequiredTemplates(&application)
+	test.EqualTo(t, "cronjob", kind)
+	test.EqualTo(t, "CronJobTemplate", required[1])
+
+	template, err := LoadTemplates("CronJobTemplate", &application)
+	test.Null(t, err)
+	test.EqualTo(t, "report-cronjob.yaml", template.Name())
+	out := &bytes.Buffer{}
+	err = template.Execute(out, &application)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), `  schedule: "@daily"
+  
+  concurrencyPolicy: Forbid
+`))
+	test.EqualTo(t, true, strings.Contains(out.String(), "  failedJobsHistoryLimit: 0\n"))
+	test.EqualTo(t, true, strings.Contains(out.String(), `          restartPolicy: Never
+          nodeSelector:
+            kubernetes.io/os: linux
+`))
+}
+
+func TestGetRequiredTemplatesStatefulSet(t *testing.T) {
+	application := Application{
+		ReleaseName:    "apps",
+		Name:           "store",
+		Kind:           "StatefulSet",
+		Replicas:       "3",
+		ServiceEnabled: true,
+		UpdateStrategy: "RollingUpdate",
+		Partition:      1,
+		VolumeClaims: []VolumeClaim{
+			{Name: "data", MountPath: "/data", AccessModes: []string{"ReadWriteOnce"}, StorageClass: "fast", Size: "10Gi"},
+		},
+	}
+	required, kind := GetRequiredTemplates(&application)
+	test.EqualTo(t, "statefulset", kind)
+	test.EqualTo(t, "HeadlessServiceTemplate", required[2])
+	test.EqualTo(t, "apps-store-headless", application.GoverningService())
+
+	template, err := LoadTemplates("StatefulSetTemplate", &application)
+	test.Null(t, err)
+	test.EqualTo(t, "store-statefulset.yaml", template.Name())
+	out := &bytes.Buffer{}
+	err = template.Execute(out, &application)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), `  serviceName: apps-store-headless
+  
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      partition: 1
+`))
+	test.EqualTo(t, true, strings.Contains(out.String(), `  volumeClaimTemplates:
+  - metadata:
+      name: data
+      labels:
+        app: store
+        release: apps
+    spec:
+      accessModes:
+      - ReadWriteOnce
+      storageClassName: fast
+      resources:
+        requests:
+          storage: 10Gi
+  template:
+`))
+	test.EqualTo(t, true, strings.Contains(out.String(), `         volumeMounts:
+          - name: data
+            mountPath: /data
+`))
+
+	application.Headless = true
+	required, _ = GetRequiredTemplates(&application)
+	test.EqualTo(t, "ServiceTemplate", required[2])
+	test.EqualTo(t, "apps-store", application.GoverningService())
+}
+
+func TestPatchTemplatesOmitEmptyEnv(t *testing.T) {
+	application := Application{ReleaseName: "apps", Name: "store", Replicas: "1"}
+	for _, tName := range []string{"DeploymentPatchTemplate", "JobPatchTemplate", "CronJobPatchTemplate", "StatefulSetPatchTemplate"} {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		test.EqualTo(t, false, strings.Contains(out.String(), "env:"))
+		test.EqualTo(t, false, strings.Contains(out.String(), "resources:"))
+	}
+
+	application.EnvVars = map[string]string{"LOG_LEVEL": "DEBUG"}
+	template, err := LoadTemplates("DeploymentPatchTemplate", &application)
+	test.Null(t, err)
+	out := &bytes.Buffer{}
+	err = template.Execute(out, &application)
+	test.Null(t, err)
+	test.EqualTo(t, true, strings.Contains(out.String(), "env:\n          - name: \"LOG_LEVEL\"\n            value: \"DEBUG\""))
+}
+
+func TestWorkloadTemplatesOmitEmptyEnv(t *testing.T) {
+	application := Application{
+		ReleaseName:   "apps",
+		Name:          "store",
+		Replicas:      "1",
+		Schedule:      "@daily",
+		ConfigEnvVars: map[string]string{"GREETING": "hi"},
+		Sidecars:      []Sidecar{{Name: "proxy", Image: "envoy"}},
+	}
+	for _, tName := range []string{"DeploymentTemplate", "StatefulSetTemplate", "JobTemplate", "CronJobTemplate"} {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		document := make(map[interface{}]interface{})
+		err = yaml.Unmarshal(out.Bytes(), &document)
+		test.Null(t, err)
+		test.EqualTo(t, false, strings.Contains(out.String(), "env:"))
+		test.EqualTo(t, true, strings.Contains(out.String(), "envFrom:"))
+	}
+}
+
+func TestPatchTemplatesSetTheImage(t *testing.T) {
+	application := Application{ReleaseName: "apps", Name: "store", Replicas: "1", Tag: "1.0", ImageRepository: "mirror.local:5000/store"}
+	for _, tName := range []string{"DeploymentPatchTemplate", "JobPatchTemplate", "CronJobPatchTemplate", "StatefulSetPatchTemplate"} {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		test.EqualTo(t, true, strings.Contains(out.String(), "image: mirror.local:5000/store:1.0\n"))
+	}
+}
+
+func TestTemplatesQuoteFreeFormValues(t *testing.T) {
+	value := "say \"hi\" from C:\\tmp\nand: {{ .Name }}"
+	values := map[string]string{"greeting": value}
+	application := Application{
+		ReleaseName:        "apps",
+		Name:               "store",
+		Replicas:           "1",
+		EnvVars:            values,
+		SecretEnvVars:      values,
+		ConfigEnvVars:      values,
+		PodAnnotations:     values,
+		ServiceEnabled:     true,
+		ServiceAnnotations: values,
+		Ports:              []Port{{Name: "http", Port: 8080}},
+		Sidecars:           []Sidecar{{Name: "proxy", Image: "envoy", Env: values}},
+	}
+	for _, tName := range []string{"DeploymentTemplate", "StatefulSetTemplate", "JobTemplate", "CronJobTemplate", "DeploymentPatchTemplate",
+		"JobPatchTemplate", "CronJobPatchTemplate", "StatefulSetPatchTemplate", "SecretTemplate", "ConfigMapTemplate", "ServiceTemplate"} {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		document := make(map[string]interface{})
+		err = yaml.Unmarshal(out.Bytes(), &document)
+		test.Null(t, err)
+		test.EqualTo(t, true, strings.Contains(out.String(), `"say \"hi\" from C:\\tmp\nand: {{ .Name }}"`))
+	}
+
+	template, err := LoadTemplates("ConfigMapTemplate", &application)
+	test.Null(t, err)
+	out := &bytes.Buffer{}
+	err = template.Execute(out, &application)
+	test.Null(t, err)
+	configMap := struct {
+		Data map[string]string `yaml:"data"`
+	}{}
+	err = yaml.Unmarshal(out.Bytes(), &configMap)
+	test.Null(t, err)
+	test.EqualTo(t, value, configMap.Data["GREETING"])
+}
+
+func TestWorkloadTemplatesShareContainersAndPlacement(t *testing.T) {
+	application := Application{
+		ReleaseName: "apps",
+		Name:        "report",
+		Tag:         "1.0",
+		Replicas:    "1",
+		Schedule:    "@daily",
+		Command:     []string{"run"},
+		EnvVars:     map[string]string{"LOG_LEVEL": "DEBUG"},
+		Limits:      map[string]string{"cpu": "1", "memory": "1Gi"},
+		Volumes:     []Volume{{Name: "cache", MountPath: "/cache"}},
+		Sidecars:    []Sidecar{{Name: "proxy", Image: "envoy", Port: 9901}},
+		Placement: &model.Placement{
+			NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+			Tolerations:  []model.Toleration{{Key: "dedicated", Operator: "Exists", Effect: "NoSchedule"}},
+		},
+	}
+	type podSpec struct {
+		Containers   []map[string]interface{} `yaml:"containers"`
+		NodeSelector map[string]string        `yaml:"nodeSelector"`
+		Tolerations  []map[string]string      `yaml:"tolerations"`
+	}
+	render := func(tName string) string {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		return out.String()
+	}
+
+	type podTemplate struct {
+		Spec struct {
+			Template struct {
+				Spec podSpec `yaml:"spec"`
+			} `yaml:"template"`
+		} `yaml:"spec"`
+	}
+	deployment := podTemplate{}
+	err := yaml.Unmarshal([]byte(render("DeploymentTemplate")), &deployment)
+	test.Null(t, err)
+	statefulSet := podTemplate{}
+	err = yaml.Unmarshal([]byte(render("StatefulSetTemplate")), &statefulSet)
+	test.Null(t, err)
+	cronJob := struct {
+		Spec struct {
+			JobTemplate struct {
+				Spec struct {
+					Template struct {
+						Spec podSpec `yaml:"spec"`
+					} `yaml:"template"`
+				} `yaml:"spec"`
+			} `yaml:"jobTemplate"`
+		} `yaml:"spec"`
+	}{}
+	err = yaml.Unmarshal([]byte(render("CronJobTemplate")), &cronJob)
+	test.Null(t, err)
+
+	pod := deployment.Spec.Template.Spec
+	test.EqualTo(t, 2, len(pod.Containers))
+	test.EqualTo(t, "proxy", pod.Containers[1]["name"])
+	test.EqualTo(t, "linux", pod.NodeSelector["kubernetes.io/os"])
+	test.EqualTo(t, "dedicated", pod.Tolerations[0]["key"])
+	test.EqualTo(t, true, reflect.DeepEqual(pod, cronJob.Spec.JobTemplate.Spec.Template.Spec))
+	test.EqualTo(t, true, reflect.DeepEqual(pod, statefulSet.Spec.Template.Spec))
+
+	deploymentPatch := podTemplate{}
+	err = yaml.Unmarshal([]byte(render("DeploymentPatchTemplate")), &deploymentPatch)
+	test.Null(t, err)
+	statefulSetPatch := podTemplate{}
+	err = yaml.Unmarshal([]byte(render("StatefulSetPatchTemplate")), &statefulSetPatch)
+	test.Null(t, err)
+	patch := deploymentPatch.Spec.Template.Spec
+	test.EqualTo(t, 1, len(patch.Containers))
+	test.EqualTo(t, "report:1.0", patch.Containers[0]["image"])
+	test.EqualTo(t, true, reflect.DeepEqual(patch, statefulSetPatch.Spec.Template.Spec))
+}
+
+func TestTemplatesQuoteTheVersionLabel(t *testing.T) {
+	application := Application{
+		ReleaseName:    "apps",
+		Name:           "store",
+		Tag:            "23.10",
+		Replicas:       "1",
+		Schedule:       "@daily",
+		EnvVars:        map[string]string{"LOG_LEVEL": "DEBUG"},
+		SecretEnvVars:  map[string]string{"DB_PASSWORD": "s3cret"},
+		ConfigEnvVars:  map[string]string{"GREETING": "hi"},
+		ServiceEnabled: true,
+		Ports:          []Port{{Name: "http", Port: 8080}},
+	}
+	var versions func(node interface{}) []interface{}
+	versions = func(node interface{}) []interface{} {
+		found := make([]interface{}, 0)
+		switch n := node.(type) {
+		case map[interface{}]interface{}:
+			for k, v := range n {
+				if k == "version" {
+					found = append(found, v)
+				}
+				found = append(found, versions(v)...)
+			}
+		case []interface{}:
+			for _, v := range n {
+				found = append(found, versions(v)...)
+			}
+		}
+		return found
+	}
+	for _, tName := range []string{"DeploymentTemplate", "StatefulSetTemplate", "JobTemplate", "CronJobTemplate", "ServiceTemplate",
+		"HeadlessServiceTemplate", "ServiceAccountTemplate", "RoleTemplate", "SecretTemplate", "ConfigMapTemplate"} {
+		template, err := LoadTemplates(tName, &application)
+		test.Null(t, err)
+		out := &bytes.Buffer{}
+		err = template.Execute(out, &application)
+		test.Null(t, err)
+		document := make(map[interface{}]interface{})
+		err = yaml.Unmarshal(out.Bytes(), &document)
+		test.Null(t, err)
+		found := versions(document)
+		test.EqualTo(t, true, len(found) > 0)
+		for _, v := range found {
+			test.EqualTo(t, interface{}("23.10"), v)
+		}
+	}
 }
@@ -1,16 +1,23 @@
 package templates
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"gopkg.in/yaml.v2"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
-var ChartTemplate = `apiVersion: v1
+//ConfigHashAnnotation is the pod template annotation holding the hash of the ConfigMap of an app
+const ConfigHashAnnotation = "checksum/config"
+
+var ChartTemplate = `apiVersion: v2
 description: A Helm chart for Kubernetes {{ .ReleaseName }}
-name: {{ .ReleaseName }}
-version: 1.0
+name: {{ .ReleaseName | ToLower }}
+type: application
+version: {{ .ChartVersion }}
 `
 
 var ServiceAccountTemplate = `apiVersion: v1
@@ -21,7 +28,7 @@ metadata:
   labels:
     app: {{ .Name }}
     release: {{ .ReleaseName }}
-    version: {{ .Tag }}
+    version: {{ Quote .Tag }}
 `
 
 var ServiceTemplate = `apiVersion: v1
@@ -32,19 +39,108 @@ metadata:
   labels:
     app: {{ .Name }}
     release: {{ .ReleaseName }}
-    version: {{ .Tag }}
+    version: {{ Quote .Tag }}
+  {{ if .ServiceAnnotations -}}annotations:{{ range $key, $value := .ServiceAnnotations }}
+    {{ $key }}: {{ Quote $value }}{{ end }}{{- end }}
 spec:
-  type: ClusterIP
-  ports:
-  - name: http
-    port: 80
-    targetPort: http
-    protocol: TCP
+  type: {{ .ServiceType }}
+  {{ if .Headless -}}clusterIP: None
+  {{ end -}}ports:{{ range $port := .Ports }}
+  - name: {{ $port.Name }}
+    port: {{ $port.Port }}
+    targetPort: {{ $port.Name }}
+    protocol: {{ $port.Protocol }}{{ if $port.AppProtocol }}
+    appProtocol: {{ $port.AppProtocol }}{{ end }}{{ if $port.NodePort }}
+    nodePort: {{ $port.NodePort }}{{ end }}{{ end }}
   selector:
     app: {{ .Name }}
     release: {{ .ReleaseName }}
 `
 
+//HeadlessServiceTemplate is the governing service of a StatefulSet, it publishes the address of every pod
+var HeadlessServiceTemplate = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .GoverningService }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+spec:
+  clusterIP: None
+  publishNotReadyAddresses: true
+  {{ if .Ports -}}ports:{{ range $port := .Ports }}
+  - name: {{ $port.Name }}
+    port: {{ $port.Port }}
+    targetPort: {{ $port.Name }}
+    protocol: {{ $port.Protocol }}{{ end }}
+  {{ end -}}
+  selector:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+`
+
+var RoleTemplate = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+rules:{{ range $rule := .Rules }}
+- apiGroups: [{{ range $group := $rule.ApiGroups }}"{{ $group }}", {{ end }}]
+  resources: [{{ range $resource := $rule.Resources }}"{{ $resource }}", {{ end }}]
+  verbs: [{{ range $verb := $rule.Verbs }}"{{ $verb }}", {{ end }}]{{ end }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+subjects:
+- kind: ServiceAccount
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+`
+
+var SecretTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+type: Opaque
+stringData:{{ range $key, $value := .SecretEnvVars }}
+  "{{ $key | ToUpper }}": {{ Quote $value }}{{ end }}
+`
+
+var ConfigMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+data:{{ range $key, $value := .ConfigEnvVars }}
+  "{{ $key | ToUpper }}": {{ Quote $value }}{{ end }}
+`
+
 var DeploymentTemplate = `apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -53,7 +149,7 @@ metadata:
   labels:
     app: {{ .Name }}
     release: {{ .ReleaseName }}
-    version: {{ .Tag }}
+    version: {{ Quote .Tag }}
   annotations:{{ if .Annotations }}
     {{ range $key, $value := .Annotations }}{{ $key }}: {{ $value }}
     {{ end }}{{ end }}
@@ -68,44 +164,78 @@ spec:
       labels:
         app: {{ .Name }}
         release: {{ .ReleaseName }}
+      {{ if .PodAnnotations -}}annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}{{- end }}
     spec:
-      serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
-      containers:
-       - name: {{ .Name }}
-         image: {{ .Name}}:{{ .Tag}}
-         imagePullPolicy: IfNotPresent
-         {{ if .Entrypoint }}command: [{{ range $entry := .Entrypoint }}'{{$entry}}', {{ end }}]{{ end }}
-         {{ if .Command }}args: [{{ range $cmd := .Command }}'{{$cmd}}', {{ end }}]{{ end }}
-
-         {{ if .ServiceEnabled -}}ports:
-         - name: http
-           containerPort: {{ .ContainerPort }}
-           protocol: TCP {{- end }}
-         {{ if .LivenessProbe -}}livenessProbe:
-           httpGet:
-             path: {{ .LivenessProbe }}
-             port: http
-           initialDelaySeconds: 30
-           timeoutSeconds: 100{{- end }}
-         {{ if .ReadinessProbe -}}readinessProbe:
-           httpGet:
-             path: {{ .ReadinessProbe }}
-             port: http
-           initialDelaySeconds: 30
-           timeoutSeconds: 100 {{- end }}
-         resources:
-           limits:
-             cpu: "{{ index .Limits "cpu" }}"
-             memory:  "{{ index .Limits "memory" }}"   
-           requests:
-             cpu:  "{{ index .Limits "cpu" }}"
-             memory:  "{{ index .Limits "memory" }}"
-         env:{{ range $key, $value := .EnvVars }}
-          - name: "{{ $key | ToUpper }}"
-            value: "{{ $value }}"{{end}}
-      affinity:
-      nodeSelector:
-      tolerations:
+      serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}{{ if .ImagePullSecrets }}
+      imagePullSecrets:{{ range .ImagePullSecrets }}
+      - name: {{ . }}{{ end }}{{ end }}
+      containers:{{ Include "container" . | Indent 7 }}{{ range .Sidecars }}{{ Include "sidecar" . | Indent 7 }}{{ end }}
+      {{ if .Volumes -}}volumes:{{ range $volume := .Volumes }}
+      - name: {{ $volume.Name }}
+        {{ if $volume.Secret }}secret:
+          secretName: {{ $volume.Secret }}{{ else if $volume.ConfigMap }}configMap:
+          name: {{ $volume.ConfigMap }}{{ else }}emptyDir: {}{{ end }}{{ end }}{{- end }}
+      {{- Include "placement" .Placement | Indent 6 }}
+`
+
+//StatefulSetTemplate gives every pod a stable name from the governing service and the volumes of its claims
+var StatefulSetTemplate = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+  annotations:{{ if .Annotations }}
+    {{ range $key, $value := .Annotations }}{{ $key }}: {{ $value }}
+    {{ end }}{{ end }}
+spec:
+  replicas: {{ .Replicas }}
+  serviceName: {{ .GoverningService }}
+  {{ if .PodManagementPolicy -}}podManagementPolicy: {{ .PodManagementPolicy }}{{- end }}
+  {{ if .UpdateStrategy -}}updateStrategy:
+    type: {{ .UpdateStrategy }}{{ if .Partition }}
+    rollingUpdate:
+      partition: {{ .Partition }}{{ end }}{{- end }}
+  selector:
+    matchLabels:
+      app: {{ .Name }}
+      release: {{ .ReleaseName }}
+  {{ if .VolumeClaims -}}volumeClaimTemplates:{{ range $claim := .VolumeClaims }}
+  - metadata:
+      name: {{ $claim.Name }}
+      labels:
+        app: {{ $.Name }}
+        release: {{ $.ReleaseName }}
+    spec:
+      accessModes:{{ range $claim.AccessModes }}
+      - {{ . }}{{ end }}{{ if $claim.StorageClass }}
+      storageClassName: {{ $claim.StorageClass }}{{ end }}
+      resources:
+        requests:
+          storage: {{ $claim.Size }}{{ end }}
+  {{ end -}}
+  template:
+    metadata:
+      labels:
+        app: {{ .Name }}
+        release: {{ .ReleaseName }}
+      {{ if .PodAnnotations -}}annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}{{- end }}
+    spec:
+      serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}{{ if .ImagePullSecrets }}
+      imagePullSecrets:{{ range .ImagePullSecrets }}
+      - name: {{ . }}{{ end }}{{ end }}
+      containers:{{ Include "container" . | Indent 7 }}{{ range .Sidecars }}{{ Include "sidecar" . | Indent 7 }}{{ end }}
+      {{ if .Volumes -}}volumes:{{ range $volume := .Volumes }}
+      - name: {{ $volume.Name }}
+        {{ if $volume.Secret }}secret:
+          secretName: {{ $volume.Secret }}{{ else if $volume.ConfigMap }}configMap:
+          name: {{ $volume.ConfigMap }}{{ else }}emptyDir: {}{{ end }}{{ end }}{{- end }}
+      {{- Include "placement" .Placement | Indent 6 }}
 `
 
 var JobTemplate = `apiVersion: batch/v1
@@ -116,7 +246,7 @@ metadata:
   labels:
     app: {{ .Name }}
     release: {{ .ReleaseName }}
-    version: {{ .Tag }}
+    version: {{ Quote .Tag }}
   annotations:{{ if .Annotations }}
     {{ range $key, $value := .Annotations }}{{ $key }}: {{ $value }}
     {{ end }}{{ end }}
@@ -127,29 +257,250 @@ spec:
   {{ if .ActiveDeadLine -}}activeDeadlineSeconds: {{ .ActiveDeadLine }}{{- end }}
   {{ if .TTLSecondsAfterFinished -}}ttlSecondsAfterFinished: {{ .TTLSecondsAfterFinished }}{{- end }}
   template:
-    spec:
-      serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
-      containers:
-       - name: {{ .Name }}
-         image: {{ .Name}}:{{ .Tag}}
-         imagePullPolicy: IfNotPresent
-         {{ if .Entrypoint }}command: [{{ range $entry := .Entrypoint }}'{{$entry}}', {{ end }}]{{ end }}
-         {{ if .Command }}args: [{{ range $cmd := .Command }}'{{$cmd}}', {{ end }}]{{ end }}
-
-         resources:
-           limits:
-             cpu: "{{ index .Limits "cpu" }}"
-             memory:  "{{ index .Limits "memory" }}"   
-           requests:
-             cpu:  "{{ index .Limits "cpu" }}"
-             memory:  "{{ index .Limits "memory" }}"
-         env:{{ range $key, $value := .EnvVars }}
-          - name: "{{ $key | ToUpper }}"
-            value: "{{ $value }}"{{end}}
+    {{ if .PodAnnotations -}}metadata:
+      annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}
+    {{ end -}}spec:
+      serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}{{ if .ImagePullSecrets }}
+      imagePullSecrets:{{ range .ImagePullSecrets }}
+      - name: {{ . }}{{ end }}{{ end }}
+      containers:{{ Include "container" . | Indent 7 }}{{ range .Sidecars }}{{ Include "sidecar" . | Indent 7 }}{{ end }}
+      {{ if .Volumes -}}volumes:{{ range $volume := .Volumes }}
+      - name: {{ $volume.Name }}
+        {{ if $volume.Secret }}secret:
+          secretName: {{ $volume.Secret }}{{ else if $volume.ConfigMap }}configMap:
+          name: {{ $volume.ConfigMap }}{{ else }}emptyDir: {}{{ end }}{{ end }}{{- end }}
       restartPolicy: {{ if .RestartPolicy -}}{{ .RestartPolicy }}{{ else }}Never{{end}} 
-      affinity:
-      nodeSelector:
-      tolerations:
+      {{- Include "placement" .Placement | Indent 6 }}
+`
+
+//CronJobTemplate runs the pod of a Job on a schedule
+var CronJobTemplate = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .Name }}
+    release: {{ .ReleaseName }}
+    version: {{ Quote .Tag }}
+  annotations:{{ if .Annotations }}
+    {{ range $key, $value := .Annotations }}{{ $key }}: {{ $value }}
+    {{ end }}{{ end }}
+spec:
+  schedule: "{{ .Schedule }}"
+  {{ if .TimeZone -}}timeZone: {{ .TimeZone }}{{- end }}
+  {{ if .ConcurrencyPolicy -}}concurrencyPolicy: {{ .ConcurrencyPolicy }}{{- end }}
+  {{ if .StartingDeadline -}}startingDeadlineSeconds: {{ .StartingDeadline }}{{- end }}
+  {{ with .SuccessfulJobsHistory -}}successfulJobsHistoryLimit: {{ . }}{{- end }}
+  {{ with .FailedJobsHistory -}}failedJobsHistoryLimit: {{ . }}{{- end }}
+  jobTemplate:
+    spec:
+      completions: {{ if .Replicas }}{{ .Replicas }}{{ else }}1{{ end }}
+      {{ if .Parallelism -}}parallelism: {{ .Parallelism }}{{- end }}
+      {{ if .BackoffLimit -}}backoffLimit: {{ .BackoffLimit }}{{- end }}
+      {{ if .ActiveDeadLine -}}activeDeadlineSeconds: {{ .ActiveDeadLine }}{{- end }}
+      {{ if .TTLSecondsAfterFinished -}}ttlSecondsAfterFinished: {{ .TTLSecondsAfterFinished }}{{- end }}
+      template:
+        {{ if .PodAnnotations -}}metadata:
+          annotations:{{ range $key, $value := .PodAnnotations }}
+            {{ $key }}: {{ Quote $value }}{{ end }}
+        {{ end -}}spec:
+          serviceAccountName: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}{{ if .ImagePullSecrets }}
+          imagePullSecrets:{{ range .ImagePullSecrets }}
+          - name: {{ . }}{{ end }}{{ end }}
+          containers:{{ Include "container" . | Indent 11 }}{{ range .Sidecars }}{{ Include "sidecar" . | Indent 11 }}{{ end }}
+          {{ if .Volumes -}}volumes:{{ range $volume := .Volumes }}
+          - name: {{ $volume.Name }}
+            {{ if $volume.Secret }}secret:
+              secretName: {{ $volume.Secret }}{{ else if $volume.ConfigMap }}configMap:
+              name: {{ $volume.ConfigMap }}{{ else }}emptyDir: {}{{ end }}{{ end }}{{- end }}
+          restartPolicy: {{ if .RestartPolicy -}}{{ .RestartPolicy }}{{ else }}Never{{end}} 
+          {{- Include "placement" .Placement | Indent 10 }}
+`
+
+//ProbeTemplate renders the action and timings of a probe of a workload container
+var ProbeTemplate = `{{ define "probe" }}{{ with .HttpGet }}
+httpGet:{{ if .Path }}
+  path: {{ .Path }}{{ end }}
+  port: {{ .Port }}{{ if .Scheme }}
+  scheme: {{ .Scheme }}{{ end }}{{ if .HttpHeaders }}
+  httpHeaders:{{ range .HttpHeaders }}
+  - name: {{ .Name }}
+    value: {{ printf "%q" .Value }}{{ end }}{{ end }}{{ end }}{{ with .TcpSocket }}
+tcpSocket:
+  port: {{ .Port }}{{ end }}{{ with .Exec }}
+exec:
+  command:{{ range .Command }}
+  - {{ printf "%q" . }}{{ end }}{{ end }}{{ with .Grpc }}
+grpc:
+  port: {{ .Port }}{{ if .Service }}
+  service: {{ .Service }}{{ end }}{{ end }}{{ if .InitialDelaySeconds }}
+initialDelaySeconds: {{ .InitialDelaySeconds }}{{ end }}{{ if .PeriodSeconds }}
+periodSeconds: {{ .PeriodSeconds }}{{ end }}{{ if .TimeoutSeconds }}
+timeoutSeconds: {{ .TimeoutSeconds }}{{ end }}{{ if .SuccessThreshold }}
+successThreshold: {{ .SuccessThreshold }}{{ end }}{{ if .FailureThreshold }}
+failureThreshold: {{ .FailureThreshold }}{{ end }}{{ if .TerminationGracePeriodSeconds }}
+terminationGracePeriodSeconds: {{ .TerminationGracePeriodSeconds }}{{ end }}{{ end }}`
+
+//PlacementTemplate renders the scheduling constraints of a pod spec, indented by the caller
+var PlacementTemplate = `{{ define "placement" }}{{ with . }}{{ if .NodeSelector }}
+nodeSelector:{{ ToYaml .NodeSelector | Indent 2 }}{{ end }}{{ if .Affinity }}
+affinity:{{ ToYaml .Affinity | Indent 2 }}{{ end }}{{ if .Tolerations }}
+tolerations:{{ ToYaml .Tolerations | Indent 0 }}{{ end }}{{ if .TopologySpreadConstraints }}
+topologySpreadConstraints:{{ ToYaml .TopologySpreadConstraints | Indent 0 }}{{ end }}{{ end }}{{ end }}`
+
+//ContainerTemplate renders the app container and a sidecar container of a pod spec, and the app container of a
+//kustomize patch, indented by the caller
+var ContainerTemplate = `{{ define "container" }}- name: {{ .Name }}
+  image: {{ .ImageRef }}
+  imagePullPolicy: {{ if .ImagePullPolicy }}{{ .ImagePullPolicy }}{{ else }}IfNotPresent{{ end }}
+  {{ if .Entrypoint }}command: [{{ range $entry := .Entrypoint }}'{{$entry}}', {{ end }}]{{ end }}
+  {{ if .Command }}args: [{{ range $cmd := .Command }}'{{$cmd}}', {{ end }}]{{ end }}{{ if .Ports }}
+  ports:{{ range $port := .Ports }}
+  - name: {{ $port.Name }}
+    containerPort: {{ $port.ContainerPort }}
+    protocol: {{ $port.Protocol }}{{ end }}{{ end }}{{ if .LivenessProbe }}
+  livenessProbe:{{ Include "probe" .LivenessProbe | Indent 4 }}{{ end }}{{ if .ReadinessProbe }}
+  readinessProbe:{{ Include "probe" .ReadinessProbe | Indent 4 }}{{ end }}{{ if .StartupProbe }}
+  startupProbe:{{ Include "probe" .StartupProbe | Indent 4 }}{{ end }}
+  resources:{{ if .Limits }}
+    limits:
+      cpu: "{{ index .Limits "cpu" }}"
+      memory: "{{ index .Limits "memory" }}"{{ end }}{{ if .Requests }}
+    requests:
+      cpu: "{{ index .Requests "cpu" }}"
+      memory: "{{ index .Requests "memory" }}"{{ end }}
+  {{ if or .EnvVars .SecretEnvVars -}}env:{{ range $key, $value := .EnvVars }}
+   - name: "{{ $key | ToUpper }}"
+     value: {{ Quote $value }}{{end}}{{ range $key, $value := .SecretEnvVars }}
+   - name: "{{ $key | ToUpper }}"
+     valueFrom:
+       secretKeyRef:
+         name: {{ $.ReleaseName | ToLower }}-{{ $.Name | ToLower }}
+         key: "{{ $key | ToUpper }}"{{ end }}{{- end }}
+  {{ if .ConfigEnvVars -}}envFrom:
+   - configMapRef:
+       name: {{ .ReleaseName | ToLower }}-{{ .Name | ToLower }}{{- end }}
+  {{ if or .Volumes .VolumeClaims -}}volumeMounts:{{ range $volume := .Volumes }}{{ if $volume.MountPath }}
+   - name: {{ $volume.Name }}
+     mountPath: {{ $volume.MountPath }}{{ if $volume.ReadOnly }}
+     readOnly: true{{ end }}{{ end }}{{ end }}{{ range $claim := .VolumeClaims }}
+   - name: {{ $claim.Name }}
+     mountPath: {{ $claim.MountPath }}{{ end }}{{- end }}{{ end }}
+{{ define "sidecar" }}- name: {{ .Name }}
+  image: {{ .Image }}
+  imagePullPolicy: IfNotPresent
+  {{ if .Args }}args: [{{ range $arg := .Args }}'{{$arg}}', {{ end }}]{{ end }}
+  {{ if .Port -}}ports:
+  - containerPort: {{ .Port }}
+    protocol: TCP{{- end }}
+  {{ if .Env -}}env:{{ range $key, $value := .Env }}
+   - name: "{{ $key | ToUpper }}"
+     value: {{ Quote $value }}{{end}}{{- end }}
+  {{ if .VolumeMounts -}}volumeMounts:{{ range $mount := .VolumeMounts }}
+   - name: {{ $mount.Name }}
+     mountPath: {{ $mount.MountPath }}{{ if $mount.ReadOnly }}
+     readOnly: true{{ end }}{{ end }}{{- end }}{{ end }}
+{{ define "container-patch" }}- name: {{ .Name }}
+  image: {{ .ImageRef }}
+  {{ if or .Limits .Requests -}}resources:{{ if .Limits }}
+    limits:
+      cpu: "{{ index .Limits "cpu" }}"
+      memory: "{{ index .Limits "memory" }}"{{ end }}{{ if .Requests }}
+    requests:
+      cpu: "{{ index .Requests "cpu" }}"
+      memory: "{{ index .Requests "memory" }}"{{ end }}{{- end }}
+  {{ if or .EnvVars .SecretEnvVars -}}env:{{ range $key, $value := .EnvVars }}
+   - name: "{{ $key | ToUpper }}"
+     value: {{ Quote $value }}{{end}}{{ range $key, $value := .SecretEnvVars }}
+   - name: "{{ $key | ToUpper }}"
+     valueFrom:
+       secretKeyRef:
+         name: {{ $.ReleaseName | ToLower }}-{{ $.Name | ToLower }}
+         key: "{{ $key | ToUpper }}"{{ end }}{{- end }}
+  {{ if .ConfigEnvVars -}}envFrom:
+   - configMapRef:
+       name: {{ .ReleaseName | ToLower }}-{{ .Name | ToLower }}{{- end }}{{ end }}`
+
+var DeploymentPatchTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+spec:
+  replicas: {{ .Replicas }}
+  template:
+    {{ if .PodAnnotations -}}metadata:
+      annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}
+    {{ end -}}spec:
+      containers:{{ Include "container-patch" . | Indent 7 }}
+      {{- Include "placement" .Placement | Indent 6 }}
+`
+
+var JobPatchTemplate = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+spec:
+  {{ if .Replicas -}}completions: {{ .Replicas }}{{- end }}
+  template:
+    {{ if .PodAnnotations -}}metadata:
+      annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}
+    {{ end -}}spec:
+      containers:{{ Include "container-patch" . | Indent 7 }}
+      {{- Include "placement" .Placement | Indent 6 }}
+`
+
+var CronJobPatchTemplate = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+spec:
+  jobTemplate:
+    spec:
+      {{ if .Replicas -}}completions: {{ .Replicas }}{{- end }}
+      template:
+        {{ if .PodAnnotations -}}metadata:
+          annotations:{{ range $key, $value := .PodAnnotations }}
+            {{ $key }}: {{ Quote $value }}{{ end }}
+        {{ end -}}spec:
+          containers:{{ Include "container-patch" . | Indent 11 }}
+          {{- Include "placement" .Placement | Indent 10 }}
+`
+
+var StatefulSetPatchTemplate = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: {{ .ReleaseName | ToLower }}-{{ .Name  | ToLower }}
+  namespace: {{ .Namespace }}
+spec:
+  replicas: {{ .Replicas }}
+  {{ if .VolumeClaims -}}volumeClaimTemplates:{{ range $claim := .VolumeClaims }}
+  - metadata:
+      name: {{ $claim.Name }}
+      labels:
+        app: {{ $.Name }}
+        release: {{ $.ReleaseName }}
+    spec:
+      accessModes:{{ range $claim.AccessModes }}
+      - {{ . }}{{ end }}{{ if $claim.StorageClass }}
+      storageClassName: {{ $claim.StorageClass }}{{ end }}
+      resources:
+        requests:
+          storage: {{ $claim.Size }}{{ end }}
+  {{ end -}}
+  template:
+    {{ if .PodAnnotations -}}metadata:
+      annotations:{{ range $key, $value := .PodAnnotations }}
+        {{ $key }}: {{ Quote $value }}{{ end }}
+    {{ end -}}spec:
+      containers:{{ Include "container-patch" . | Indent 7 }}
+      {{- Include "placement" .Placement | Indent 6 }}
 `
 
 //LoadTemplates parse static template to helm chart
@@ -163,21 +514,82 @@ func LoadTemplates(tName string, app *Application) (*template.Template, error) {
 		return getTemplate(fmt.Sprintf("%s-service.yaml", app.Name), ServiceTemplate)
 	case "ServiceAccountTemplate":
 		return getTemplate(fmt.Sprintf("%s-serviceaccount.yaml", app.Name), ServiceAccountTemplate)
+	case "StatefulSetTemplate":
+		return getTemplate(fmt.Sprintf("%s-statefulset.yaml", app.Name), StatefulSetTemplate)
+	case "HeadlessServiceTemplate":
+		return getTemplate(fmt.Sprintf("%s-headless-service.yaml", app.Name), HeadlessServiceTemplate)
 	case "JobTemplate":
 		return getTemplate(fmt.Sprintf("%s-job.yaml", app.Name), JobTemplate)
+	case "CronJobTemplate":
+		return getTemplate(fmt.Sprintf("%s-cronjob.yaml", app.Name), CronJobTemplate)
+	case "DeploymentPatchTemplate":
+		return getTemplate(fmt.Sprintf("%s-patch.yaml", app.Name), DeploymentPatchTemplate)
+	case "JobPatchTemplate":
+		return getTemplate(fmt.Sprintf("%s-patch.yaml", app.Name), JobPatchTemplate)
+	case "CronJobPatchTemplate":
+		return getTemplate(fmt.Sprintf("%s-patch.yaml", app.Name), CronJobPatchTemplate)
+	case "StatefulSetPatchTemplate":
+		return getTemplate(fmt.Sprintf("%s-patch.yaml", app.Name), StatefulSetPatchTemplate)
+	case "ConfigMapTemplate":
+		return getTemplate(fmt.Sprintf("%s-configmap.yaml", app.Name), ConfigMapTemplate)
+	case "SecretTemplate":
+		return getTemplate(fmt.Sprintf("%s-secret.yaml", app.Name), SecretTemplate)
+	case "RoleTemplate":
+		return getTemplate(fmt.Sprintf("%s-role.yaml", app.Name), RoleTemplate)
 	}
 	return nil, nil
 }
 
 func getTemplate(name string, templateType string) (*template.Template, error) {
+	tmpl := template.New(name)
 	funcMap := template.FuncMap{
 		"ToUpper": strings.ToUpper,
 		"ToLower": strings.ToLower,
+		"ToYaml":  toYaml,
+		"Indent":  indent,
+		"Quote":   quote,
+		"Include": func(name string, data interface{}) (string, error) {
+			return include(tmpl, name, data)
+		},
 	}
 
-	tmpl, err := template.New(name).Funcs(funcMap).Parse(templateType)
+	tmpl, err := tmpl.Funcs(funcMap).Parse(templateType + ProbeTemplate + PlacementTemplate + ContainerTemplate)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("error parsing %v ", err))
 	}
 	return tmpl, nil
 }
+
+//toYaml renders a value as a yaml block, eg: the affinity of a pod
+func toYaml(v interface{}) (string, error) {
+	content, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+//quote writes a value as a double quoted yaml string, escaping quotes, backslashes and line breaks,
+//eg: say "hi" as "say \"hi\""
+func quote(value interface{}) string {
+	return strconv.Quote(fmt.Sprint(value))
+}
+
+//include renders a defined template into a yaml block for indent, eg: the placement of a pod spec
+func include(tmpl *template.Template, name string, data interface{}) (string, error) {
+	out := &bytes.Buffer{}
+	err := tmpl.ExecuteTemplate(out, name, data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(strings.TrimLeft(out.String(), "\n"), " \n"), nil
+}
+
+//indent starts every line of a yaml block on a new line indented by spaces, an empty block stays empty
+func indent(spaces int, block string) string {
+	if block == "" {
+		return ""
+	}
+	prefix := "\n" + strings.Repeat(" ", spaces)
+	return prefix + strings.ReplaceAll(block, "\n", prefix)
+}